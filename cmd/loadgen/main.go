@@ -0,0 +1,221 @@
+// Command loadgen logs from parallel producers for a fixed duration and reports the throughput, drop rate and
+// latency percentiles of a MultiLogger from its own Metrics, to compare buffer sizes and sink setups.
+//
+//	go run ./cmd/loadgen -producers 16 -duration 30s -buffer 1000 -sinks 2 -sink-delay 20us
+//	go run ./cmd/loadgen -embedded-nats -rate 5000
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+type options struct {
+	producers    int
+	rate         int
+	duration     time.Duration
+	bufferLen    int
+	sinks        int
+	sinkDelay    time.Duration
+	level        string
+	messageSize  int
+	natsURL      string
+	embeddedNATS bool
+	subject      string
+}
+
+func main() {
+	var o options
+	flag.IntVar(&o.producers, "producers", 8, "number of goroutines logging in parallel")
+	flag.IntVar(&o.rate, "rate", 0, "entries per second of each producer, 0 logs as fast as possible")
+	flag.DurationVar(&o.duration, "duration", 10*time.Second, "how long the producers log")
+	flag.IntVar(&o.bufferLen, "buffer", 100, "bufferLen passed to NewLogger, the queue holds 10 times as many entries")
+	flag.IntVar(&o.sinks, "sinks", 1, "number of in-memory sinks discarding the entries")
+	flag.DurationVar(&o.sinkDelay, "sink-delay", 0, "time each in-memory sink spends per entry")
+	flag.StringVar(&o.level, "level", "info", "level of the entries")
+	flag.IntVar(&o.messageSize, "message-size", 128, "bytes of padding in each entry")
+	flag.StringVar(&o.natsURL, "nats", "", "also publish to the NATS server at this URL")
+	flag.BoolVar(&o.embeddedNATS, "embedded-nats", false, "also publish to a NATS server started in process")
+	flag.StringVar(&o.subject, "subject", "logs", "subject of the NATS sink")
+	flag.Parse()
+
+	if err := run(o); err != nil {
+		fmt.Fprintf(os.Stderr, "loadgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(o options) error {
+	level, err := logger.ParseLevel(o.level)
+	if err != nil {
+		return err
+	}
+
+	sinks := make([]logger.ILogger, 0, o.sinks+1)
+	for i := 0; i < o.sinks; i++ {
+		sinks = append(sinks, &discardSink{name: fmt.Sprintf("discard-%d", i), delay: o.sinkDelay})
+	}
+
+	natsURL := o.natsURL
+	if o.embeddedNATS {
+		natsServer, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoLog: true, NoSigs: true})
+		if err != nil {
+			return fmt.Errorf("failed to create NATS server: %w", err)
+		}
+		go natsServer.Start()
+		defer natsServer.Shutdown()
+		if !natsServer.ReadyForConnections(5 * time.Second) {
+			return fmt.Errorf("NATS server did not start")
+		}
+		natsURL = natsServer.ClientURL()
+	}
+	if natsURL != "" {
+		natsLogger, err := logger.NewLoggerNATS(natsURL, logger.DEBUG, logger.WithSubject(o.subject))
+		if err != nil {
+			return err
+		}
+		defer natsLogger.Close()
+		sinks = append(sinks, natsLogger)
+	}
+	if len(sinks) == 0 {
+		return fmt.Errorf("no sinks, set -sinks or a NATS server")
+	}
+
+	multiLogger := logger.NewLogger(o.bufferLen, sinks...)
+	defer multiLogger.Stop()
+
+	callLatency := logger.NewHistogram()
+	var attempted atomic.Int64
+	padding := strings.Repeat("x", o.messageSize)
+
+	fmt.Printf("Logging for %s from %d producers...\n", o.duration, o.producers)
+	start := time.Now()
+	deadline := start.Add(o.duration)
+
+	var wg sync.WaitGroup
+	for p := 0; p < o.producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			produce(multiLogger, level, p, o.rate, deadline, padding, callLatency, &attempted)
+		}(p)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	drainStart := time.Now()
+	if !multiLogger.Flush(time.Minute) {
+		fmt.Println("Warning: the queue was not drained within a minute")
+	}
+	drain := time.Since(drainStart)
+
+	report(o, multiLogger.Metrics(), callLatency.Snapshot(), attempted.Load(), elapsed, drain)
+	return nil
+}
+
+func produce(l *logger.MultiLogger, level logger.Level, producer, rate int, deadline time.Time, padding string, latency *logger.Histogram, attempted *atomic.Int64) {
+	var interval time.Duration
+	if rate > 0 {
+		interval = time.Second / time.Duration(rate)
+	}
+
+	next := time.Now()
+	for i := 0; ; i++ {
+		now := time.Now()
+		if !now.Before(deadline) {
+			return
+		}
+		if interval > 0 {
+			if wait := next.Sub(now); wait > 0 {
+				time.Sleep(wait)
+			}
+			next = next.Add(interval)
+		}
+
+		callStart := time.Now()
+		l.Logf(level, "entry %d from producer %d %s", i, producer, padding)
+		latency.Observe(time.Since(callStart))
+		attempted.Add(1)
+	}
+}
+
+func report(o options, metrics logger.MetricsSnapshot, call logger.HistogramSnapshot, attempted int64, elapsed, drain time.Duration) {
+	seconds := elapsed.Seconds()
+	dropped := metrics.ChDroppedMessages
+
+	fmt.Println()
+	fmt.Printf("bufferLen %d: queue capacity %d, DEBUG to WARN entries are dropped above %d queued entries\n",
+		o.bufferLen, o.bufferLen*10, o.bufferLen*8/10)
+	fmt.Printf("attempted  %d entries in %s, %.0f/s\n", attempted, elapsed.Round(time.Millisecond), float64(attempted)/seconds)
+	fmt.Printf("enqueued   %d, %.0f/s\n", metrics.ChProcessedMessages, float64(metrics.ChProcessedMessages)/seconds)
+	fmt.Printf("dropped    %d, %.2f%%\n", dropped, percent(dropped, attempted))
+	fmt.Printf("peak queue %d of %d\n", metrics.ChPeakUsage, o.bufferLen*10)
+	fmt.Printf("drained    in %s after the producers stopped\n", drain.Round(time.Millisecond))
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "latency\tp50\tp90\tp99\tmax\t")
+	printLatency(w, "Logf call", call)
+	printLatency(w, "enqueue to write", metrics.EnqueueLatency)
+	for _, s := range metrics.Sinks {
+		printLatency(w, s.Name+" write", s.WriteLatency)
+	}
+	w.Flush()
+	fmt.Println()
+
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "sink\tmessages\tper second\tbytes\terrors\t")
+	for _, s := range metrics.Sinks {
+		fmt.Fprintf(w, "%s\t%d\t%.0f\t%d\t%d\t\n", s.Name, s.Messages, float64(s.Messages)/seconds, s.Bytes, s.Errors)
+	}
+	w.Flush()
+}
+
+func printLatency(w *tabwriter.Writer, name string, h logger.HistogramSnapshot) {
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", name, h.Quantile(0.5), h.Quantile(0.9), h.Quantile(0.99), h.Max)
+}
+
+func percent(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
+
+// discardSink drops encoded entries after an optional delay simulating the write
+type discardSink struct {
+	name  string
+	delay time.Duration
+}
+
+func (d *discardSink) Log(level logger.Level, message string) error {
+	return nil
+}
+
+func (d *discardSink) LogMessage(level logger.Level, message logger.LogMessage) error {
+	return nil
+}
+
+func (d *discardSink) LogRaw(level logger.Level, data []byte) error {
+	if d.delay > 0 {
+		time.Sleep(d.delay)
+	}
+	return nil
+}
+
+func (d *discardSink) ShouldLogLevel(level logger.Level) bool {
+	return true
+}
+
+func (d *discardSink) Name() string {
+	return d.name
+}
@@ -16,7 +16,7 @@ func hasToStringMethod(value interface{}) bool {
 }
 
 func tryToConvertToJSON(value interface{}) []byte {
-	r, err := json.Marshal(value)
+	r, err := json.Marshal(ApplyLogTags(value))
 	if err != nil {
 		return nil
 	} else {
@@ -24,6 +24,8 @@ func tryToConvertToJSON(value interface{}) []byte {
 	}
 }
 
+// Stringify joins args into a single string, struct fields tagged `log:"-"` are left out and fields
+// tagged `log:"redact"` are replaced by RedactedValue
 func Stringify(args ...interface{}) string {
 	var builder strings.Builder
 	builder.Grow(512)
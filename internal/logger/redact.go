@@ -0,0 +1,197 @@
+package logger
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// RedactedValue replaces values of struct fields tagged `log:"redact"`
+const RedactedValue = "[REDACTED]"
+
+// maxLogTagDepth bounds the nesting walked for log tags, deeper values and cycles are marshaled as they are
+const maxLogTagDepth = 32
+
+var logTagCache sync.Map // reflect.Type -> bool
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// ApplyLogTags returns value with struct fields tagged `log:"-"` removed and fields tagged `log:"redact"`
+// replaced by RedactedValue. Only values holding such a field are rebuilt, values with their own MarshalJSON
+// or MarshalText are returned unchanged.
+func ApplyLogTags(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	if !hasLogTags(v, 0) {
+		return value
+	}
+	return convertLogTags(v, 0)
+}
+
+// isMarshaler reports whether t encodes itself, its fields are then not visible to json.Marshal
+func isMarshaler(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType)
+}
+
+// mayHaveLogTags reports whether a value of type t can hold a tagged field, true for interfaces whose dynamic
+// type is checked by hasLogTags
+func mayHaveLogTags(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if cached, ok := logTagCache.Load(t); ok {
+		return cached.(bool)
+	}
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+
+	var result bool
+	switch {
+	case t.Kind() == reflect.Interface:
+		// the dynamic type is only known at runtime
+		result = true
+	case isMarshaler(t):
+		result = false
+	case t.Kind() == reflect.Pointer, t.Kind() == reflect.Slice, t.Kind() == reflect.Array, t.Kind() == reflect.Map:
+		result = mayHaveLogTags(t.Elem(), visiting)
+	case t.Kind() == reflect.Struct:
+		for i := 0; i < t.NumField() && !result; i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			result = f.Tag.Get("log") != "" || mayHaveLogTags(f.Type, visiting)
+		}
+	}
+
+	delete(visiting, t)
+	logTagCache.Store(t, result)
+	return result
+}
+
+// hasLogTags reports whether v holds a tagged field, looking through the dynamic values of interfaces
+func hasLogTags(v reflect.Value, depth int) bool {
+	if depth > maxLogTagDepth || !v.IsValid() || !mayHaveLogTags(v.Type(), map[reflect.Type]bool{}) {
+		return false
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		return !v.IsNil() && hasLogTags(v.Elem(), depth+1)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if f.Tag.Get("log") != "" || hasLogTags(v.Field(i), depth+1) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if hasLogTags(v.Index(i), depth+1) {
+				return true
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if hasLogTags(iter.Value(), depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func convertLogTags(v reflect.Value, depth int) interface{} {
+	if !hasLogTags(v, depth) {
+		if !v.IsValid() || (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) && v.IsNil() {
+			return nil
+		}
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		return convertLogTags(v.Elem(), depth+1)
+	case reflect.Struct:
+		out := make(map[string]interface{}, v.NumField())
+		convertStruct(v, out, depth)
+		return out
+	case reflect.Slice, reflect.Array:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = convertLogTags(v.Index(i), depth+1)
+		}
+		return out
+	case reflect.Map:
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(iter.Key().Interface())] = convertLogTags(iter.Value(), depth+1)
+		}
+		return out
+	default:
+		return v.Interface()
+	}
+}
+
+// convertStruct writes the fields of a struct into out following the encoding/json naming rules
+func convertStruct(v reflect.Value, out map[string]interface{}, depth int) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		logTag := f.Tag.Get("log")
+		if logTag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		fv := v.Field(i)
+		if strings.Contains(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+
+		if name == "" {
+			if f.Anonymous && logTag == "" {
+				embedded := fv
+				if embedded.Kind() == reflect.Pointer {
+					if embedded.IsNil() {
+						continue
+					}
+					embedded = embedded.Elem()
+				}
+				if embedded.Kind() == reflect.Struct {
+					convertStruct(embedded, out, depth+1)
+					continue
+				}
+			}
+			name = f.Name
+		}
+
+		if logTag == "redact" {
+			out[name] = RedactedValue
+		} else {
+			out[name] = convertLogTags(fv, depth+1)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package grpclogger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Options configures the interceptors
+type Options struct {
+	// CodeLevel returns the level of the entry of a call, DefaultCodeLevel when nil
+	CodeLevel func(code codes.Code) logger.Level
+
+	// RequestIDMetadata is the metadata key carrying the request ID, x-request-id when empty
+	RequestIDMetadata string
+}
+
+func (o Options) withDefaults() Options {
+	if o.CodeLevel == nil {
+		o.CodeLevel = DefaultCodeLevel
+	}
+	if o.RequestIDMetadata == "" {
+		o.RequestIDMetadata = strings.ToLower(logger.DefaultRequestIDHeader)
+	}
+	return o
+}
+
+// DefaultCodeLevel logs successful calls at INFO, errors caused by the caller at WARN and server side
+// failures at ERROR
+func DefaultCodeLevel(code codes.Code) logger.Level {
+	switch code {
+	case codes.OK:
+		return logger.INFO
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied,
+		codes.Unauthenticated, codes.ResourceExhausted, codes.FailedPrecondition, codes.Aborted, codes.OutOfRange:
+		return logger.WARN
+	default:
+		return logger.ERROR
+	}
+}
+
+// UnaryServerInterceptor logs every unary call with its method, peer, status code and duration. The handler
+// context carries l and the request_id, grpc_service, grpc_method and peer fields, the request ID and the W3C
+// traceparent are read from the incoming metadata.
+func UnaryServerInterceptor(l *logger.MultiLogger, options Options) grpc.UnaryServerInterceptor {
+	options = options.withDefaults()
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx = serverContext(ctx, l, info.FullMethod, options)
+
+		resp, err := handler(ctx, req)
+
+		logCall(l, ctx, options, "unary", start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor logs every streaming call like UnaryServerInterceptor, with the number of messages
+// sent and received
+func StreamServerInterceptor(l *logger.MultiLogger, options Options) grpc.StreamServerInterceptor {
+	options = options.withDefaults()
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		stream := &serverStream{ServerStream: ss, ctx: serverContext(ss.Context(), l, info.FullMethod, options)}
+
+		err := handler(srv, stream)
+
+		logCall(l, stream.ctx, options, "stream", start, err, "messages_sent", stream.sent.Load(), "messages_received", stream.received.Load())
+		return err
+	}
+}
+
+// UnaryClientInterceptor logs every outgoing unary call and sends the request_id field of the context in the
+// outgoing metadata
+func UnaryClientInterceptor(l *logger.MultiLogger, options Options) grpc.UnaryClientInterceptor {
+	options = options.withDefaults()
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		var p peer.Peer
+		ctx = clientContext(ctx, method, options)
+
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(&p))...)
+
+		logCall(l, withPeer(ctx, &p), options, "unary", start, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor logs every outgoing streaming call when it ends, with the number of messages sent
+// and received. A call ends with its response for client streaming, with its status otherwise, or when its
+// context is done for a server stream the caller stops reading.
+func StreamClientInterceptor(l *logger.MultiLogger, options Options) grpc.StreamClientInterceptor {
+	options = options.withDefaults()
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		ctx = clientContext(ctx, method, options)
+
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			logCall(l, ctx, options, "stream", start, err)
+			return nil, err
+		}
+
+		stream := &clientStream{ClientStream: cs, serverStreams: desc.ServerStreams}
+		done := func(err error) {
+			stream.once.Do(func() {
+				if errors.Is(err, io.EOF) {
+					err = nil
+				}
+				logCall(l, ctx, options, "stream", start, err, "messages_sent", stream.sent.Load(), "messages_received", stream.received.Load())
+			})
+		}
+		stop := context.AfterFunc(ctx, func() {
+			done(status.FromContextError(ctx.Err()).Err())
+		})
+		stream.finish = func(err error) {
+			stop()
+			done(err)
+		}
+		return stream, nil
+	}
+}
+
+// serverContext adds the logger, the call fields and the remote span of the incoming metadata to ctx
+func serverContext(ctx context.Context, l *logger.MultiLogger, fullMethod string, options Options) context.Context {
+	service, method := splitMethod(fullMethod)
+	fields := []interface{}{"grpc_service", service, "grpc_method", method}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(options.RequestIDMetadata); len(values) > 0 && values[0] != "" {
+		fields = append(fields, "request_id", values[0])
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields = append(fields, "peer", p.Addr.String())
+	}
+
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		if values := md.Get("traceparent"); len(values) > 0 {
+			if spanContext, ok := parseTraceparent(values[0]); ok {
+				ctx = trace.ContextWithRemoteSpanContext(ctx, spanContext)
+			}
+		}
+	}
+
+	return logger.WithContextFields(logger.NewContext(ctx, l), fields...)
+}
+
+// clientContext adds the call fields to ctx and propagates the request ID in the outgoing metadata
+func clientContext(ctx context.Context, fullMethod string, options Options) context.Context {
+	service, method := splitMethod(fullMethod)
+
+	if requestID, ok := logger.ContextFields(ctx)["request_id"].(string); ok && requestID != "" {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		if len(md.Get(options.RequestIDMetadata)) == 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, options.RequestIDMetadata, requestID)
+		}
+	}
+
+	return logger.WithContextFields(ctx, "grpc_service", service, "grpc_method", method)
+}
+
+func withPeer(ctx context.Context, p *peer.Peer) context.Context {
+	if p.Addr == nil {
+		return ctx
+	}
+	return logger.WithContextFields(ctx, "peer", p.Addr.String())
+}
+
+func logCall(l *logger.MultiLogger, ctx context.Context, options Options, kind string, start time.Time, err error, fields ...interface{}) {
+	code := status.Code(err)
+	level := options.CodeLevel(code)
+	if !l.IsEnabled(level) {
+		return
+	}
+
+	duration := time.Since(start)
+	fields = append(fields, "grpc_code", code.String(), "duration_ms", float64(duration.Microseconds())/1000)
+	if err != nil {
+		fields = append(fields, "error", status.Convert(err).Message())
+	}
+
+	service, method := "", ""
+	if values := logger.ContextFields(ctx); values != nil {
+		service, _ = values["grpc_service"].(string)
+		method, _ = values["grpc_method"].(string)
+	}
+
+	l.LogContextf(level, logger.WithContextFields(ctx, fields...), "grpc %s call /%s/%s %s in %s", kind, service, method, code, duration)
+}
+
+// splitMethod splits /package.Service/Method into its service and method
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
+
+// parseTraceparent parses a W3C traceparent value: version-traceid-spanid-flags
+func parseTraceparent(value string) (trace.SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || len(parts[3]) != 2 {
+		return trace.SpanContext{}, false
+	}
+
+	traceID, err := trace.TraceIDFromHex(parts[1])
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	spanID, err := trace.SpanIDFromHex(parts[2])
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+
+	var flags trace.TraceFlags
+	if parts[3] == "01" {
+		flags = trace.FlagsSampled
+	}
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: flags, Remote: true})
+	return spanContext, spanContext.IsValid()
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	sent     atomic.Int64
+	received atomic.Int64
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	}
+	return err
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received.Add(1)
+	}
+	return err
+}
+
+type clientStream struct {
+	grpc.ClientStream
+	serverStreams bool
+	sent          atomic.Int64
+	received      atomic.Int64
+	once          sync.Once
+	finish        func(err error)
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	} else if !errors.Is(err, io.EOF) {
+		// io.EOF means the stream ended, its status is returned by RecvMsg
+		s.finish(err)
+	}
+	return err
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		s.received.Add(1)
+		// Without server streaming the only response ends the call, RecvMsg is not called again
+		if !s.serverStreams {
+			s.finish(nil)
+		}
+	} else {
+		s.finish(err)
+	}
+	return err
+}
@@ -4,11 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/CoreKitMDK/corekit-service-logger/v2/internal/logger"
 )
 
 var Logger IMultiLogger = NewLogger(100, NewLoggerConsole(DEBUG))
@@ -27,10 +26,16 @@ const (
 
 // LogMessage represents the structure of a log message sent to NATS
 type LogMessage struct {
-	Timestamp string            `json:"timestamp"`
-	Level     string            `json:"level"`
-	Message   string            `json:"message"`
-	Tags      map[string]string `json:"tags"`
+	Timestamp string                 `json:"timestamp"`
+	Level     string                 `json:"level"`
+	Message   string                 `json:"message"`
+	Tags      map[string]string      `json:"tags"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+
+	// TraceID, SpanID and TraceFlags correlate entries logged with a context with their OpenTelemetry span
+	TraceID    string `json:"trace_id,omitempty"`
+	SpanID     string `json:"span_id,omitempty"`
+	TraceFlags string `json:"trace_flags,omitempty"`
 }
 
 func LogLevelToString(l Level) string {
@@ -50,6 +55,24 @@ func LogLevelToString(l Level) string {
 	}
 }
 
+// ParseLevel converts a level name such as "info" or "ERROR" to a Level
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "DEBUG":
+		return DEBUG, nil
+	case "INFO":
+		return INFO, nil
+	case "WARN", "WARNING":
+		return WARN, nil
+	case "ERROR":
+		return ERROR, nil
+	case "FATAL":
+		return FATAL, nil
+	default:
+		return UNKNOWN, fmt.Errorf("invalid log level %q", name)
+	}
+}
+
 func isValidLogLevel(level Level) bool {
 	return level >= DEBUG && level <= UNKNOWN
 }
@@ -68,37 +91,168 @@ type ILogger interface {
 	ShouldLogLevel(level Level) bool
 }
 
+// INamedLogger is implemented by sinks that report a name for metrics
+type INamedLogger interface {
+	Name() string
+}
+
 type logEntry struct {
-	level   Level
-	message string
+	level     Level
+	message   string
+	body      string // message without timestamp prefix and stack trace, used to detect duplicates
+	fields    map[string]interface{}
+	trace     traceContext
+	timestamp time.Time // when the entry was logged, set for entries that may be written late
+	enqueued  time.Time
+	allSinks  bool          // written to every sink whatever the routing rules
+	deadline  time.Time     // set on priority entries, they wait for room in the queue until then instead of being dropped
+	flushed   chan struct{} // set on the marker queued by Flush, closed once the entries before it are written
+}
+
+// sink wraps an ILogger with its name and metrics
+type sink struct {
+	name    string
+	logger  ILogger
+	latency *Histogram
+	metrics sinkMetrics
+}
+
+// LoggerOption is a functional option for configuring the MultiLogger
+type LoggerOption func(*MultiLogger)
+
+// WithMinLevel drops entries below level before they are formatted, whatever the sink levels
+func WithMinLevel(level Level) LoggerOption {
+	return func(l *MultiLogger) {
+		l.minLevel.Store(int32(level))
+	}
+}
+
+// WithTags adds tags to every entry, on top of the hostname tag
+func WithTags(tags map[string]string) LoggerOption {
+	return func(l *MultiLogger) {
+		for key, value := range tags {
+			l.tags[key] = value
+		}
+	}
 }
 
 type MultiLogger struct {
-	loggers   []ILogger
 	bufferLen int
-	tags      map[string]string
 	logCh     chan logEntry
 	quitLogCh chan struct{}
-	stopped   bool
+	stopped   atomic.Bool
 	metrics   Metrics
+	minLevel  atomic.Int32
+
+	latencyBuckets []time.Duration
+	heartbeat      *heartbeat
+	dedup          *dedup
+	processors     []IProcessor
+	spanEvents     bool
+
+	// options given to NewLoggerWithOptions, Reconfigure applies the configuration on top of them
+	options []LoggerOption
+
+	// writeMutex is held for reading while an entry is written to the sinks, without blocking the callers on
+	// mutex, Reconfigure takes it to wait for the writes to the sinks it removed before closing them
+	writeMutex sync.RWMutex
+
+	// reconfigureMutex serializes Reconfigure calls from start to end, mutex guards the fields they replace
+	reconfigureMutex sync.Mutex
+	mutex            sync.RWMutex
+	sinks            []*sink
+	tags             map[string]string
+	sampling         *sampling
+	redactor         *Redactor
+	router           *router
+	configuration    *Configuration
 }
 
 func (l *MultiLogger) processLog(entry logEntry) {
+	if entry.flushed != nil {
+		close(entry.flushed)
+		return
+	}
+
 	start := time.Now()
 
+	l.writeMutex.RLock()
+	defer l.writeMutex.RUnlock()
+
+	// Sinks can block, the fields are copied so that the callers reading them are not held up by a Reconfigure
+	// waiting for the lock
+	l.mutex.RLock()
+	sinks, tags, redactor, router := l.sinks, l.tags, l.redactor, l.router
+	l.mutex.RUnlock()
+
 	var didLog = false
 
-	for _, logger := range l.loggers {
-		if logger.ShouldLogLevel(entry.level) {
+	timestamp := entry.timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	logMsg := LogMessage{
+		Timestamp:  timestamp.Format(time.RFC3339),
+		Level:      LogLevelToString(entry.level),
+		Message:    entry.message,
+		Tags:       tags,
+		Fields:     entry.fields,
+		TraceID:    entry.trace.traceID,
+		SpanID:     entry.trace.spanID,
+		TraceFlags: entry.trace.traceFlags,
+	}
+
+	if redactor != nil {
+		redactor.RedactMessage(&logMsg)
+	}
+
+	if !l.runProcessors(&logMsg) {
+		l.metrics.ProcessorDroppedMessagesInc()
+		return
+	}
+
+	var targets map[string]bool
+	if router != nil && !entry.allSinks {
+		targets = router.targets(entry.level, &logMsg)
+	}
+
+	// The entry is encoded once, on the first sink accepting raw bytes
+	var encoded *encodeBuffer
+	var encodeErr error
+	defer func() {
+		if encoded != nil {
+			putEncodeBuffer(encoded)
+		}
+	}()
+
+	for _, s := range sinks {
+		if targets != nil && !targets[s.name] {
+			continue
+		}
 
-			logMsg := LogMessage{
-				Timestamp: time.Now().Format(time.RFC3339),
-				Level:     LogLevelToString(entry.level),
-				Message:   entry.message,
-				Tags:      l.tags,
+		if s.logger.ShouldLogLevel(entry.level) {
+			writeStart := time.Now()
+			var err error
+			// Raw sinks receive the whole encoded entry, the others are counted by their message text
+			written := len(logMsg.Message)
+			if raw := rawSink(s.logger); raw != nil {
+				if encoded == nil {
+					encoded = getEncodeBuffer()
+					encodeErr = encoded.appendLogMessage(&logMsg)
+				}
+				err = encodeErr
+				if err == nil {
+					err = raw.LogRaw(entry.level, encoded.data)
+					written = len(encoded.data)
+				}
+			} else {
+				err = s.logger.LogMessage(entry.level, logMsg)
 			}
+			s.latency.Observe(time.Since(writeStart))
+			s.recordWrite(written, err)
 
-			if err := logger.LogMessage(entry.level, logMsg); err != nil {
+			if err != nil {
 				fallbackLog(entry.level, fmt.Sprintln("Error logging message: ", err))
 				l.metrics.LoggerFailed()
 			} else {
@@ -107,19 +261,32 @@ func (l *MultiLogger) processLog(entry logEntry) {
 		}
 	}
 
-	if !didLog {
-		fallbackLog(entry.level, entry.message)
+	if didLog {
+		l.metrics.EnqueueLatency.Observe(time.Since(entry.enqueued))
+	} else {
+		fallbackLog(entry.level, logMsg.Message)
 	}
 
 	l.metrics.ChMessageProcessingTimeMsAvgAdd(time.Since(start).Milliseconds())
 }
 
-func (l *MultiLogger) log(level Level, message string) {
+func (l *MultiLogger) log(entry logEntry) {
+	if l.dedup != nil && !l.dedup.add(entry, time.Now()) {
+		l.metrics.DeduplicatedMessagesInc()
+		return
+	}
+
+	l.enqueue(entry)
+}
+
+func (l *MultiLogger) enqueue(entry logEntry) {
+	level := entry.level
 
 	l.metrics.ChTotalMessagesInc()
+	l.metrics.LevelCountInc(level)
 	l.metrics.ChCurrentUsageSet(len(l.logCh))
 
-	if float32(len(l.logCh))/float32(l.bufferLen) > 0.8 {
+	if entry.deadline.IsZero() && float32(len(l.logCh))/float32(l.bufferLen) > 0.8 {
 		if level == DEBUG || level == INFO || level == WARN {
 			// Skip verbose logging for low-priority messages
 			//fallbackLog(level, " [OVERFLOW] Channel near full capacity ignoring low priority message: "+message)
@@ -128,14 +295,34 @@ func (l *MultiLogger) log(level Level, message string) {
 		}
 	}
 
+	entry.enqueued = time.Now()
+
+	if !entry.deadline.IsZero() {
+		timer := time.NewTimer(time.Until(entry.deadline))
+		defer timer.Stop()
+
+		select {
+		case l.logCh <- entry:
+			l.metrics.ChProcessedMessagesInc()
+		case <-timer.C:
+			fallbackLog(level, " [OVERFLOW] Channel full until the deadline ignoring priority message: "+l.redact(entry.message))
+			l.metrics.ChDroppedMessagesInc()
+		case <-l.quitLogCh:
+			fallbackLog(level, " [STOPPED] Logger stopped ignoring priority message: "+l.redact(entry.message))
+			l.metrics.ChDroppedMessagesInc()
+		}
+		return
+	}
+
 	select {
-	case l.logCh <- logEntry{level, message}:
+	case l.logCh <- entry:
 		l.metrics.ChProcessedMessagesInc()
 	default:
+		message := l.redact(entry.message)
 		fallbackLog(level, "Channel overflow detected: "+message)
 		if level == ERROR || level == FATAL {
 			go func() {
-				l.processLog(logEntry{level, message})
+				l.processLog(entry)
 			}()
 		} else {
 			fallbackLog(level, " [OVERFLOW] Channel overflowed ignoring low priority message: "+message)
@@ -144,7 +331,23 @@ func (l *MultiLogger) log(level Level, message string) {
 	}
 }
 
+// redact redacts text leaving the logger outside of the sinks, such as fallback messages and span events,
+// entries reaching the sinks are redacted by processLog
+func (l *MultiLogger) redact(message string) string {
+	l.mutex.RLock()
+	redactor := l.redactor
+	l.mutex.RUnlock()
+
+	if redactor == nil {
+		return message
+	}
+	return redactor.Redact(message)
+}
+
 func (l *MultiLogger) formatTags() string {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
 	if len(l.tags) == 0 {
 		return ""
 	}
@@ -185,6 +388,11 @@ func (l *MultiLogger) startWorker() {
 }
 
 func NewLogger(bufferLen int, loggers ...ILogger) *MultiLogger {
+	return NewLoggerWithOptions(bufferLen, loggers)
+}
+
+// NewLoggerWithOptions creates a MultiLogger writing to the given loggers, configured by options
+func NewLoggerWithOptions(bufferLen int, loggers []ILogger, options ...LoggerOption) *MultiLogger {
 	hostname, err := os.Hostname()
 	if err != nil {
 		fmt.Printf("Error getting hostname: %v\n", err)
@@ -195,11 +403,9 @@ func NewLogger(bufferLen int, loggers ...ILogger) *MultiLogger {
 	tags["hostname"] = hostname
 
 	logger := &MultiLogger{
-		loggers:   loggers,
 		bufferLen: bufferLen,
 		logCh:     make(chan logEntry, bufferLen*10),
 		quitLogCh: make(chan struct{}),
-		stopped:   false,
 		tags:      tags,
 		metrics: Metrics{
 			AliveSince:                   time.Now(),
@@ -220,21 +426,82 @@ func NewLogger(bufferLen int, loggers ...ILogger) *MultiLogger {
 			UnknownCount:                 0,
 		},
 	}
+
+	logger.options = options
+	for _, opt := range options {
+		opt(logger)
+	}
+
+	logger.metrics.EnqueueLatency = NewHistogram(logger.latencyBuckets...)
+	for i, l := range loggers {
+		logger.sinks = append(logger.sinks, &sink{
+			name:    sinkName(l, i),
+			logger:  l,
+			latency: NewHistogram(logger.latencyBuckets...),
+		})
+	}
+
 	go logger.startWorker()
+	if logger.heartbeat != nil {
+		go logger.startHeartbeat()
+	}
+	if logger.sampling != nil {
+		go logger.startSamplingSummary(logger.sampling)
+	}
+	if logger.dedup != nil {
+		go logger.startDedup()
+	}
 	return logger
 }
 
+func sinkName(logger ILogger, index int) string {
+	if named, ok := logger.(INamedLogger); ok {
+		return named.Name()
+	}
+	return fmt.Sprintf("sink-%d", index)
+}
+
+// IsEnabled reports whether entries of the given level pass the logger minimum level
+func (l *MultiLogger) IsEnabled(level Level) bool {
+	return level >= Level(l.minLevel.Load())
+}
+
 func (l *MultiLogger) Stop() {
-	l.stopped = true
+	l.stopped.Store(true)
 	close(l.quitLogCh)
 }
 
+// Flush waits until the entries queued before the call are written to the sinks, it reports false if they
+// are not written within timeout or the logger is stopped
+func (l *MultiLogger) Flush(timeout time.Duration) bool {
+	if l.stopped.Load() {
+		return false
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	flushed := make(chan struct{})
+	select {
+	case l.logCh <- logEntry{flushed: flushed}:
+	case <-timer.C:
+		return false
+	}
+
+	select {
+	case <-flushed:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
 func (l *MultiLogger) Log(level Level, args ...interface{}) {
 	if len(args) == 0 {
 		return
 	}
 
-	if l.stopped == true {
+	if l.stopped.Load() {
 		fallbackLog(ERROR, fmt.Sprintln("Error logging message: ", "logger is stopped ", level))
 		return
 	}
@@ -244,24 +511,26 @@ func (l *MultiLogger) Log(level Level, args ...interface{}) {
 		return
 	}
 
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-
-	var builder strings.Builder
+	if !l.IsEnabled(level) || !l.allow(level, messageTemplate(args)) {
+		return
+	}
 
-	builder.WriteString(fmt.Sprintf("%s - [%s] : ", timestamp, LogLevelToString(level)))
+	e := getEncodeBuffer()
+	defer putEncodeBuffer(e)
 
-	builder.WriteString(logger.Stringify(args))
+	e.data = appendMessagePrefix(e.data, level)
+	bodyStart := len(e.data)
+	e.appendArgs(args)
+	bodyEnd := len(e.data)
 
 	if level == FATAL || level == ERROR {
-		buf := make([]byte, 1<<16)
-		bufLen := runtime.Stack(buf, true)
-		builder.WriteString("\n Stack trace : \n")
-		builder.WriteString(string(buf[:bufLen]))
+		e.data = appendStackTrace(e.data)
 	}
 
-	builder.WriteString("\n")
+	e.data = append(e.data, '\n')
 
-	l.log(level, builder.String())
+	message := string(e.data)
+	l.log(logEntry{level: level, message: message, body: entryBody(level, message, bodyStart, bodyEnd)})
 }
 
 func (l *MultiLogger) Logf(level Level, format string, args ...interface{}) {
@@ -269,7 +538,7 @@ func (l *MultiLogger) Logf(level Level, format string, args ...interface{}) {
 		return
 	}
 
-	if l.stopped == true {
+	if l.stopped.Load() {
 		fallbackLog(ERROR, fmt.Sprintln("Error logging message: ", "logger is stopped ", level))
 		return
 	}
@@ -279,9 +548,12 @@ func (l *MultiLogger) Logf(level Level, format string, args ...interface{}) {
 		return
 	}
 
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	formattedMessage := fmt.Sprintf("%s - [%s] : ", timestamp, LogLevelToString(level)) + fmt.Sprintf(format, args...)
-	l.log(level, formattedMessage)
+	if !l.IsEnabled(level) || !l.allow(level, format) {
+		return
+	}
+
+	body := fmt.Sprintf(format, args...)
+	l.log(logEntry{level: level, message: formatMessage(level, body), body: body})
 }
 
 func (l *MultiLogger) LogJson(level Level, args ...interface{}) {
@@ -295,8 +567,10 @@ func (l *MultiLogger) LogJson(level Level, args ...interface{}) {
 	l.Log(level, args...)
 }
 
+// LogContext logs the values of the given context keys, entries also carry the fields added with
+// WithContextFields and the OpenTelemetry span of ctx
 func (l *MultiLogger) LogContext(level Level, ctx context.Context, keys ...interface{}) {
-	if l.stopped {
+	if l.stopped.Load() {
 		fallbackLog(ERROR, fmt.Sprintln("Error logging message: ", "logger is stopped ", level))
 		return
 	}
@@ -306,33 +580,65 @@ func (l *MultiLogger) LogContext(level Level, ctx context.Context, keys ...inter
 		return
 	}
 
+	if !l.IsEnabled(level) || !l.allow(level, "") {
+		return
+	}
+
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	contextData := extractKnownContextKeys(ctx, keys)
+	e := getEncodeBuffer()
+	defer putEncodeBuffer(e)
 
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	var builder strings.Builder
-
-	builder.WriteString(fmt.Sprintf("%s - [%s] : ", timestamp, LogLevelToString(level)))
-	if len(contextData) > 0 {
-		builder.WriteString("Context: [")
-		for key, value := range contextData {
-			builder.WriteString(fmt.Sprintf("%s=%v ", key, value))
+	e.data = appendMessagePrefix(e.data, level)
+	bodyStart := len(e.data)
+	found := false
+	for _, key := range keys {
+		value := ctx.Value(key)
+		if value == nil {
+			continue
 		}
-		builder.WriteString("] ")
+		if !found {
+			e.data = append(e.data, "Context: ["...)
+			found = true
+		}
+		e.data = fmt.Appendf(e.data, "%s=%v ", key, value)
+	}
+	if found {
+		e.data = append(e.data, "] "...)
 	}
-	builder.WriteString("\n")
+	bodyEnd := len(e.data)
+	e.data = append(e.data, '\n')
 
 	if level == FATAL || level == ERROR {
-		buf := make([]byte, 1<<16)
-		bufLen := runtime.Stack(buf, true)
-		builder.WriteString("\n Stack trace : \n")
-		builder.WriteString(string(buf[:bufLen]))
+		e.data = appendStackTrace(e.data)
 	}
 
-	l.log(level, builder.String())
+	message := string(e.data)
+	body := entryBody(level, message, bodyStart, bodyEnd)
+	l.recordSpanEvent(ctx, level, body)
+	l.logScoped(ctx, logEntry{level: level, message: message, body: body, fields: ContextFields(ctx), trace: traceFromContext(ctx)})
+}
+
+// entryBody returns the body of an entry, message[start:end]. The body outlives the message in the dedup
+// window, it is copied when the message also holds a stack trace.
+func entryBody(level Level, message string, start, end int) string {
+	if level == FATAL || level == ERROR {
+		return strings.Clone(message[start:end])
+	}
+	return message[start:end]
+}
+
+// logInternal logs an entry of the logger itself, it is not subject to the minimum level or sampling so that
+// configuration changes are always visible
+func (l *MultiLogger) logInternal(level Level, format string, args ...interface{}) {
+	if l.stopped.Load() {
+		return
+	}
+
+	body := fmt.Sprintf(format, args...)
+	l.log(logEntry{level: level, message: formatMessage(level, body), body: body})
 }
 
 func fallbackLog(level Level, message string) {
@@ -342,13 +648,3 @@ func fallbackLog(level Level, message string) {
 		return
 	}
 }
-
-func extractKnownContextKeys(ctx context.Context, keys ...interface{}) map[interface{}]interface{} {
-	result := make(map[interface{}]interface{})
-	for _, key := range keys {
-		if value := ctx.Value(key); value != nil {
-			result[key] = value
-		}
-	}
-	return result
-}
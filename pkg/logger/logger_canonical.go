@@ -0,0 +1,208 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+type canonicalContextKey struct{}
+
+// CanonicalLine accumulates the fields of a unit of work, such as a request, and logs them in a single entry
+// when the work ends. Its methods are safe for concurrent use and do nothing on a nil CanonicalLine, so code
+// along the request path can call Canonical(ctx).Set without checking.
+type CanonicalLine struct {
+	ctx     context.Context
+	name    string
+	start   time.Time
+	level   Level
+	fields  map[string]interface{}
+	emitted bool
+	mutex   sync.Mutex
+}
+
+// NewCanonicalContext starts a canonical line named after the unit of work, it is logged by Emit with the
+// logger and the fields of the returned context
+func NewCanonicalContext(ctx context.Context, name string) (context.Context, *CanonicalLine) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	line := &CanonicalLine{
+		name:   name,
+		start:  time.Now(),
+		level:  INFO,
+		fields: make(map[string]interface{}),
+	}
+	ctx = context.WithValue(ctx, canonicalContextKey{}, line)
+	line.ctx = ctx
+	return ctx, line
+}
+
+// Canonical returns the canonical line of ctx, nil when there is none
+func Canonical(ctx context.Context) *CanonicalLine {
+	if ctx == nil {
+		return nil
+	}
+	line, _ := ctx.Value(canonicalContextKey{}).(*CanonicalLine)
+	return line
+}
+
+// Set sets fields given as key-value pairs, like WithContextFields
+func (c *CanonicalLine) Set(fields ...interface{}) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i := 0; i < len(fields); i++ {
+		key, ok := fields[i].(string)
+		if !ok || i == len(fields)-1 {
+			c.fields[BadKey] = fields[i]
+			continue
+		}
+		c.fields[key] = fields[i+1]
+		i++
+	}
+}
+
+// Add adds n to the counter key, such as cache_hits. A value set before with Set is added to whatever its
+// numeric type, a value that is not a number is left unchanged.
+func (c *CanonicalLine) Add(key string, n int64) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	i, f, isFloat, ok := canonicalNumber(c.fields[key])
+	switch {
+	case !ok:
+		fallbackLog(WARN, fmt.Sprintf("Canonical line field %s holds %T, not a number, Add ignored", key, c.fields[key]))
+	case isFloat:
+		c.fields[key] = f + float64(n)
+	default:
+		c.fields[key] = i + n
+	}
+}
+
+// AddDuration adds d to the duration key in milliseconds, such as db_time. A value set before with Set is
+// added to whatever its numeric type, a value that is not a number is left unchanged.
+func (c *CanonicalLine) AddDuration(key string, d time.Duration) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	i, f, isFloat, ok := canonicalNumber(c.fields[key])
+	if !ok {
+		fallbackLog(WARN, fmt.Sprintf("Canonical line field %s holds %T, not a number, AddDuration ignored", key, c.fields[key]))
+		return
+	}
+	if !isFloat {
+		f = float64(i)
+	}
+	c.fields[key] = f + float64(d.Microseconds())/1000
+}
+
+// canonicalNumber returns value as an int64 or, for floats, a float64. A missing value is the integer 0, ok is
+// false for values that are not numbers.
+func canonicalNumber(value interface{}) (i int64, f float64, isFloat bool, ok bool) {
+	if value == nil {
+		return 0, 0, false, true
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), 0, false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(v.Uint()), 0, false, true
+	case reflect.Float32, reflect.Float64:
+		return 0, v.Float(), true, true
+	default:
+		return 0, 0, false, false
+	}
+}
+
+// RaiseLevel logs the line at level if it is above the current level, INFO by default
+func (c *CanonicalLine) RaiseLevel(level Level) {
+	if c == nil || !isValidLogLevel(level) {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if level > c.level {
+		c.level = level
+	}
+}
+
+// Fields returns a copy of the accumulated fields
+func (c *CanonicalLine) Fields() map[string]interface{} {
+	if c == nil {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	fields := make(map[string]interface{}, len(c.fields))
+	for key, value := range c.fields {
+		fields[key] = value
+	}
+	return fields
+}
+
+// Level returns the level the line is logged at
+func (c *CanonicalLine) Level() Level {
+	if c == nil {
+		return INFO
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.level
+}
+
+// Emit logs the line once with the accumulated fields and its duration_ms, later calls do nothing
+func (c *CanonicalLine) Emit() {
+	level, fields, ok := c.finish()
+	if !ok {
+		return
+	}
+
+	duration := time.Since(c.start)
+	fields = append(fields, "duration_ms", float64(duration.Microseconds())/1000)
+
+	logContextf(FromContext(c.ctx), level, WithContextFields(c.ctx, fields...), "%s completed in %s", c.name, duration)
+}
+
+// finish marks the line as emitted and returns its level and fields, ok is false if it was already emitted
+func (c *CanonicalLine) finish() (Level, []interface{}, bool) {
+	if c == nil {
+		return INFO, nil, false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.emitted {
+		return INFO, nil, false
+	}
+	c.emitted = true
+
+	fields := make([]interface{}, 0, 2*len(c.fields))
+	for key, value := range c.fields {
+		fields = append(fields, key, value)
+	}
+	return c.level, fields, true
+}
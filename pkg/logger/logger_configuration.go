@@ -1,49 +1,325 @@
 package logger
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"time"
+)
 
-// Logger should init itself from a json configuration
+// Logger should init itself from a json configuration.
+// Version 1 configures a console and a single NATS sink with the flat use_* and nats_* keys,
+// version 2 lists any number of sinks built by the factories added with RegisterSink.
 type Configuration struct {
+	Version int              `json:"version,omitempty"`
+	Sinks   []SinkDefinition `json:"sinks,omitempty"`
+
 	UseConsole   bool   `json:"use_console"`
 	UseNATS      bool   `json:"use_nats"`
 	NatsURL      string `json:"nats_url"`
 	NatsUsername string `json:"nats_username"`
 	NatsPassword string `json:"nats_password"`
+
+	ServiceName    string `json:"service_name"`
+	ServiceVersion string `json:"service_version"`
+
+	// MinLevel drops entries below the level before they reach any sink
+	MinLevel string            `json:"min_level"`
+	Tags     map[string]string `json:"tags"`
+
+	// EnvironmentTags adds the Kubernetes and build info tags, PodInfoPath is the Downward API volume
+	EnvironmentTags bool   `json:"environment_tags"`
+	PodInfoPath     string `json:"pod_info_path"`
+
+	// HeartbeatIntervalSeconds enables the heartbeat on the first NATS sink when greater than zero
+	HeartbeatIntervalSeconds int    `json:"heartbeat_interval_seconds"`
+	HeartbeatSubject         string `json:"heartbeat_subject"`
+
+	Sampling *SamplingConfiguration `json:"sampling,omitempty"`
+
+	// DedupWindowSeconds collapses identical entries logged within the window when greater than zero
+	DedupWindowSeconds int `json:"dedup_window_seconds"`
+
+	Redaction *RedactionConfiguration `json:"redaction,omitempty"`
+
+	// Routing sends entries to sinks by name, sinks are named after their type unless a name is set
+	Routing *Routing `json:"routing,omitempty"`
+
+	// SpanEvents records the entries logged with a context as events of their OpenTelemetry span
+	SpanEvents bool `json:"span_events"`
+
+	// DegradedStart starts without the NATS sinks whose server is unreachable and connects them in the
+	// background every RetryIntervalSeconds, entries logged meanwhile are buffered and sent once connected
+	DegradedStart        bool `json:"degraded_start"`
+	RetryIntervalSeconds int  `json:"retry_interval_seconds"`
+
+	unknownFields []string
+}
+
+// RedactionConfiguration enables the redaction of personal data and secrets
+type RedactionConfiguration struct {
+	Enabled        bool     `json:"enabled"`
+	DisableBuiltin bool     `json:"disable_builtin"`
+	Patterns       []string `json:"patterns"`
+	Fields         []string `json:"fields"`
+	Replacement    string   `json:"replacement"`
+}
+
+// SamplingConfiguration configures the sampler and the per level rate limits
+type SamplingConfiguration struct {
+	IntervalSeconds        int                               `json:"interval_seconds"`
+	First                  int                               `json:"first"`
+	Thereafter             int                               `json:"thereafter"`
+	ByCaller               bool                              `json:"by_caller"`
+	SummaryIntervalSeconds int                               `json:"summary_interval_seconds"`
+	RateLimits             map[string]RateLimitConfiguration `json:"rate_limits"` // keyed by level name
+}
+
+// RateLimitConfiguration configures the token bucket of a single level
+type RateLimitConfiguration struct {
+	PerSecond float64 `json:"per_second"`
+	Burst     int     `json:"burst"`
 }
 
 func NewConfiguration() *Configuration {
 	return &Configuration{}
 }
 
+// FromJsonString parses a configuration, unknown fields are kept to be reported by Validate
 func FromJsonString(jsonString string) (*Configuration, error) {
 	var config Configuration
 	err := json.Unmarshal([]byte(jsonString), &config) // Note the & operator here
 	if err != nil {
 		return nil, err
 	}
+	config.unknownFields = unknownJSONFields([]byte(jsonString), reflect.TypeOf(config), "")
 	return &config, nil
 }
 
+// Init builds the logger, sinks that fail to build are left out, use InitE to get the errors
 func (c *Configuration) Init() *MultiLogger {
+	loggers, publisher, _ := c.buildSinks()
+
+	multiLogger := NewLoggerWithOptions(100, loggers, c.loggerOptions(publisher)...)
+	multiLogger.configuration = c
+	// The options come from the configuration, a reloaded configuration replaces them
+	multiLogger.options = nil
+	return multiLogger
+}
+
+// InitE builds the logger and fails with every configuration and connection error found.
+// With DegradedStart unreachable NATS servers are not an error, their sinks connect in the background.
+func (c *Configuration) InitE() (*MultiLogger, error) {
+	if errs := c.validateStatic(); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid logger configuration: %w", errors.Join(errs...))
+	}
+
+	loggers, publisher, err := c.buildSinks()
+	if err != nil {
+		closeSinks(loggers)
+		return nil, fmt.Errorf("invalid logger configuration: %w", err)
+	}
+
+	multiLogger := NewLoggerWithOptions(100, loggers, c.loggerOptions(publisher)...)
+	multiLogger.configuration = c
+	// The options come from the configuration, a reloaded configuration replaces them
+	multiLogger.options = nil
+
+	for _, sink := range loggers {
+		if natsLogger, ok := unwrapSink(sink).(*NATS); ok && !natsLogger.IsConnected() {
+			multiLogger.Log(WARN, fmt.Sprintf("NATS sink %s is not connected, retrying in the background", sinkName(sink, 0)))
+		}
+	}
+
+	return multiLogger, nil
+}
+
+// buildSinks builds every configured sink, the first publisher found is returned for the heartbeat
+func (c *Configuration) buildSinks() ([]ILogger, IPublisher, error) {
 	var loggers []ILogger
+	var publisher IPublisher
+	var errs []error
+
+	for _, definition := range c.SinkDefinitions() {
+		sink, err := NewSink(definition)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		loggers = append(loggers, sink)
+
+		if p, ok := unwrapSink(sink).(IPublisher); ok && publisher == nil {
+			publisher = p
+		}
+	}
+
+	return loggers, publisher, errors.Join(errs...)
+}
+
+func closeSinks(loggers []ILogger) {
+	for _, sink := range loggers {
+		if closer, ok := sink.(ICloser); ok {
+			closer.Close()
+		}
+	}
+}
+
+// SinkDefinitions returns the configured sinks, translating the flat format when no sinks are listed
+func (c *Configuration) SinkDefinitions() []SinkDefinition {
+	if len(c.Sinks) > 0 {
+		if !c.DegradedStart {
+			return c.Sinks
+		}
+
+		definitions := make([]SinkDefinition, 0, len(c.Sinks))
+		for _, definition := range c.Sinks {
+			definitions = append(definitions, c.withDegradedStart(definition))
+		}
+		return definitions
+	}
+
+	var definitions []SinkDefinition
 
 	if c.UseConsole {
-		consoleLogger := NewLoggerConsole(Level(0))
-		loggers = append(loggers, consoleLogger)
+		definitions = append(definitions, NewSinkDefinition("console", "console", "", nil))
 	}
 
 	if c.UseNATS {
-		if c.NatsUsername != "" && c.NatsPassword != "" {
-			if natsLogger, err := NewLoggerNATSWithAuth(c.NatsURL, c.NatsUsername, c.NatsPassword, Level(0)); err == nil {
-				loggers = append(loggers, natsLogger)
-			}
-		} else {
-			if natsLogger, err := NewLoggerNATS(c.NatsURL, Level(0)); err == nil {
-				loggers = append(loggers, natsLogger)
-			}
+		definitions = append(definitions, c.withDegradedStart(NewSinkDefinition("nats", "nats", "", map[string]interface{}{
+			"url":      c.NatsURL,
+			"username": c.NatsUsername,
+			"password": c.NatsPassword,
+		})))
+	}
+
+	return definitions
+}
+
+// withDegradedStart returns a copy of a NATS sink definition that connects in the background
+func (c *Configuration) withDegradedStart(definition SinkDefinition) SinkDefinition {
+	if !c.DegradedStart || definition.Type != "nats" {
+		return definition
+	}
+
+	settings := make(map[string]interface{}, len(definition.settings)+2)
+	for key, value := range definition.settings {
+		settings[key] = value
+	}
+	settings["retry_on_failed_connect"] = true
+	if c.RetryIntervalSeconds > 0 {
+		settings["retry_interval_seconds"] = c.RetryIntervalSeconds
+	}
+
+	definition.settings = settings
+	return definition
+}
+
+// loggerOptions returns the MultiLogger options of everything but the sinks, publisher is used for the heartbeat
+func (c *Configuration) loggerOptions(publisher IPublisher) []LoggerOption {
+	var options []LoggerOption
+
+	if level, err := ParseLevel(c.MinLevel); err == nil {
+		options = append(options, WithMinLevel(level))
+	}
+
+	if c.EnvironmentTags {
+		options = append(options, WithEnvironmentTags(c.PodInfoPath))
+	}
+
+	if len(c.Tags) > 0 {
+		options = append(options, WithTags(c.Tags))
+	}
+
+	if c.HeartbeatIntervalSeconds > 0 && publisher != nil {
+		options = append(options, WithHeartbeat(publisher, HeartbeatOptions{
+			Service:  c.ServiceName,
+			Version:  c.ServiceVersion,
+			Subject:  c.HeartbeatSubject,
+			Interval: time.Duration(c.HeartbeatIntervalSeconds) * time.Second,
+		}))
+	}
+
+	if c.Sampling != nil {
+		options = append(options, c.Sampling.options()...)
+	}
+
+	if c.Redaction != nil && c.Redaction.Enabled {
+		redactor, err := c.Redaction.redactor()
+		if err != nil {
+			fallbackLog(ERROR, fmt.Sprintf("Invalid redaction configuration, redacting with the builtin detectors and fields only: %v", err))
 		}
+		options = append(options, WithRedactor(redactor))
 	}
 
-	multiLogger := NewLogger(100, loggers...)
-	return multiLogger
+	if c.Routing != nil {
+		options = append(options, WithRouting(*c.Routing))
+	}
+
+	if c.SpanEvents {
+		options = append(options, WithSpanEvents())
+	}
+
+	if c.DedupWindowSeconds > 0 {
+		options = append(options, WithDedup(time.Duration(c.DedupWindowSeconds)*time.Second))
+	}
+
+	return options
+}
+
+func (c *SamplingConfiguration) options() []LoggerOption {
+	var options []LoggerOption
+
+	if c.First > 0 || c.Thereafter > 0 {
+		key := SampleByTemplate
+		if c.ByCaller {
+			key = SampleByCaller
+		}
+		options = append(options, WithSampler(SamplerOptions{
+			Interval:   time.Duration(c.IntervalSeconds) * time.Second,
+			First:      c.First,
+			Thereafter: c.Thereafter,
+			Key:        key,
+		}))
+	}
+
+	for name, limit := range c.RateLimits {
+		if level, err := ParseLevel(name); err == nil {
+			options = append(options, WithRateLimit(level, limit.PerSecond, limit.Burst))
+		}
+	}
+
+	if c.SummaryIntervalSeconds > 0 {
+		options = append(options, WithSamplingSummaryInterval(time.Duration(c.SummaryIntervalSeconds)*time.Second))
+	}
+
+	return options
+}
+
+// redactor builds the redactor of the configuration, it fails closed: invalid patterns are left out and
+// reported, and the builtin detectors are then kept even if they are disabled
+func (c *RedactionConfiguration) redactor() (*Redactor, error) {
+	var options []RedactorOption
+	var errs []error
+
+	for _, expr := range c.Patterns {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("redaction.patterns: %w", err))
+			continue
+		}
+		options = append(options, WithRedactionPatterns(pattern))
+	}
+
+	options = append(options, WithRedactedFields(c.Fields...))
+
+	if c.Replacement != "" {
+		options = append(options, WithRedactionReplacement(c.Replacement))
+	}
+	if c.DisableBuiltin && len(errs) == 0 {
+		options = append(options, WithoutBuiltinDetectors())
+	}
+
+	return NewRedactor(options...), errors.Join(errs...)
 }
@@ -3,11 +3,18 @@ package logger
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"sync"
 )
 
 // Console Logging.Console implements the ILogger interface
 type Console struct {
 	minLogLevel Level
+	name        string
+
+	// buffer holds the line written by LogRaw
+	buffer []byte
+	mutex  sync.Mutex
 }
 
 func (lc *Console) LogMessage(level Level, message LogMessage) error {
@@ -19,6 +26,16 @@ func (lc *Console) LogMessage(level Level, message LogMessage) error {
 	return lc.Log(level, string(jsonBytes))
 }
 
+// LogRaw writes an entry encoded by AppendLogMessage followed by a newline
+func (lc *Console) LogRaw(level Level, data []byte) error {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	lc.buffer = append(append(lc.buffer[:0], data...), '\n')
+	_, err := os.Stdout.Write(lc.buffer)
+	return err
+}
+
 // NewLoggerConsole creates a new instance of LoggerConsole
 func NewLoggerConsole(minLogLevel Level) *Console {
 	return &Console{
@@ -36,3 +53,11 @@ func (lc *Console) Log(level Level, message string) error {
 func (lc *Console) ShouldLogLevel(level Level) bool {
 	return level >= lc.minLogLevel
 }
+
+// Name returns the sink name used in metrics
+func (lc *Console) Name() string {
+	if lc.name != "" {
+		return lc.name
+	}
+	return "console"
+}
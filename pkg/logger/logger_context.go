@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+)
+
+// BadKey is the field name of a value passed to WithContextFields without a string key
+const BadKey = "!BADKEY"
+
+type loggerContextKey struct{}
+
+type fieldsContextKey struct{}
+
+// NewContext returns a copy of ctx carrying l, retrieve it with FromContext
+func NewContext(ctx context.Context, l IMultiLogger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
+}
+
+// IContextLogger is implemented by loggers that log formatted messages with the fields of a context, such as
+// MultiLogger. It is not part of IMultiLogger so that existing implementations keep compiling, assert the
+// logger returned by FromContext to it.
+type IContextLogger interface {
+	LogContextf(level Level, context context.Context, format string, args ...interface{})
+}
+
+// FromContext returns the logger carried by ctx, the package Logger when there is none
+func FromContext(ctx context.Context) IMultiLogger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerContextKey{}).(IMultiLogger); ok {
+			return l
+		}
+	}
+	return Logger
+}
+
+// logContextf logs with LogContextf when l implements IContextLogger, with Logf otherwise
+func logContextf(l IMultiLogger, level Level, ctx context.Context, format string, args ...interface{}) {
+	if cl, ok := l.(IContextLogger); ok {
+		cl.LogContextf(level, ctx, format, args...)
+		return
+	}
+	l.Logf(level, format, args...)
+}
+
+// WithContextFields returns a copy of ctx carrying the fields of ctx plus the given key-value pairs, such as
+// "request_id", id, "tenant", tenant. They are attached to every entry logged with the context by LogContext
+// and LogContextf, a later value replaces an earlier one with the same key. A value without a string key is
+// stored under BadKey.
+func WithContextFields(ctx context.Context, fields ...interface{}) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(fields) == 0 {
+		return ctx
+	}
+
+	parent := ContextFields(ctx)
+	merged := make(map[string]interface{}, len(parent)+len(fields)/2)
+	for key, value := range parent {
+		merged[key] = value
+	}
+
+	for i := 0; i < len(fields); i++ {
+		key, ok := fields[i].(string)
+		if !ok || i == len(fields)-1 {
+			merged[BadKey] = fields[i]
+			continue
+		}
+		merged[key] = fields[i+1]
+		i++
+	}
+
+	return context.WithValue(ctx, fieldsContextKey{}, merged)
+}
+
+// ContextFields returns the fields added to ctx with WithContextFields, the map must not be modified
+func ContextFields(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsContextKey{}).(map[string]interface{})
+	return fields
+}
+
+// LogContextf logs a formatted message with the fields and the OpenTelemetry span of ctx
+func (l *MultiLogger) LogContextf(level Level, ctx context.Context, format string, args ...interface{}) {
+	if l.stopped.Load() {
+		fallbackLog(ERROR, fmt.Sprintln("Error logging message: ", "logger is stopped ", level))
+		return
+	}
+
+	if !isValidLogLevel(level) {
+		fallbackLog(ERROR, fmt.Sprintln("Error logging message: ", "invalid logger level ", level))
+		return
+	}
+
+	if !l.IsEnabled(level) || !l.allow(level, format) {
+		return
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	body := fmt.Sprintf(format, args...)
+	l.recordSpanEvent(ctx, level, body)
+	l.logScoped(ctx, logEntry{
+		level:   level,
+		message: formatMessage(level, body),
+		body:    body,
+		fields:  ContextFields(ctx),
+		trace:   traceFromContext(ctx),
+	})
+}
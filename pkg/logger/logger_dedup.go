@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dedup collapses identical entries seen within a window, the first occurrence is logged right away
+// and the repeats are summarised by a single entry when the window closes
+type dedup struct {
+	window  time.Duration
+	entries map[string]*dedupEntry
+	mutex   sync.Mutex
+}
+
+type dedupEntry struct {
+	last      logEntry
+	repeats   int64
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// WithDedup collapses entries with the same level, message and fields logged within window into one
+// entry carrying repeat_count, first_seen and last_seen fields. Tags are shared by all entries of a
+// MultiLogger so they are always identical.
+func WithDedup(window time.Duration) LoggerOption {
+	return func(l *MultiLogger) {
+		if window <= 0 {
+			return
+		}
+		l.dedup = &dedup{
+			window:  window,
+			entries: make(map[string]*dedupEntry),
+		}
+	}
+}
+
+// add records an entry and reports whether it should be logged now
+func (d *dedup) add(entry logEntry, now time.Time) bool {
+	if entry.body == "" {
+		return true
+	}
+
+	key := dedupKey(entry)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if e, ok := d.entries[key]; ok {
+		e.last = entry
+		e.repeats += 1
+		e.lastSeen = now
+		return false
+	}
+
+	d.entries[key] = &dedupEntry{firstSeen: now, lastSeen: now}
+	return true
+}
+
+// expired removes the entries whose window closed before now, or all entries if all is set
+func (d *dedup) expired(now time.Time, all bool) []*dedupEntry {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var result []*dedupEntry
+	for key, e := range d.entries {
+		if all || now.Sub(e.firstSeen) >= d.window {
+			delete(d.entries, key)
+			if e.repeats > 0 {
+				result = append(result, e)
+			}
+		}
+	}
+	return result
+}
+
+func (l *MultiLogger) startDedup() {
+	ticker := time.NewTicker(l.dedup.window / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.flushDedup(false)
+		case <-l.quitLogCh:
+			l.flushDedup(true)
+			return
+		}
+	}
+}
+
+func (l *MultiLogger) flushDedup(all bool) {
+	for _, e := range l.dedup.expired(time.Now(), all) {
+		fields := make(map[string]interface{}, len(e.last.fields)+3)
+		for key, value := range e.last.fields {
+			fields[key] = value
+		}
+		fields["repeat_count"] = e.repeats
+		fields["first_seen"] = e.firstSeen.Format(time.RFC3339Nano)
+		fields["last_seen"] = e.lastSeen.Format(time.RFC3339Nano)
+
+		entry := e.last
+		entry.fields = fields
+		l.enqueue(entry)
+	}
+}
+
+func dedupKey(entry logEntry) string {
+	var builder strings.Builder
+	builder.WriteString(LogLevelToString(entry.level))
+	builder.WriteString("|")
+	builder.WriteString(entry.body)
+
+	if len(entry.fields) > 0 {
+		keys := make([]string, 0, len(entry.fields))
+		for key := range entry.fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			builder.WriteString(fmt.Sprintf("|%s=%v", key, entry.fields[key]))
+		}
+	}
+	return builder.String()
+}
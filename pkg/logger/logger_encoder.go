@@ -0,0 +1,355 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/internal/logger"
+)
+
+// IRawLogger is implemented by sinks accepting an entry already encoded by AppendLogMessage, the entry is then
+// encoded once and the same bytes are shared by every such sink. data is only valid during the call.
+type IRawLogger interface {
+	LogRaw(level Level, data []byte) error
+}
+
+// maxPooledBuffer is the capacity above which an encode buffer is not returned to the pool
+const maxPooledBuffer = 256 * 1024
+
+// maxStackTrace is the length of the stack trace of ERROR and FATAL entries
+const maxStackTrace = 1 << 16
+
+type encodeBuffer struct {
+	data []byte
+	keys []string
+}
+
+var encodeBufferPool = sync.Pool{
+	New: func() interface{} {
+		return &encodeBuffer{data: make([]byte, 0, 2048), keys: make([]string, 0, 16)}
+	},
+}
+
+func getEncodeBuffer() *encodeBuffer {
+	return encodeBufferPool.Get().(*encodeBuffer)
+}
+
+func putEncodeBuffer(e *encodeBuffer) {
+	if cap(e.data) > maxPooledBuffer {
+		return
+	}
+	e.data = e.data[:0]
+	clear(e.keys)
+	e.keys = e.keys[:0]
+	encodeBufferPool.Put(e)
+}
+
+// AppendLogMessage appends the JSON encoding of message to dst, the output is the same as json.Marshal. Field
+// values of common types are encoded without allocating, other values go through json.Marshal.
+func AppendLogMessage(dst []byte, message *LogMessage) ([]byte, error) {
+	e := getEncodeBuffer()
+	defer putEncodeBuffer(e)
+
+	e.data = dst
+	err := e.appendLogMessage(message)
+	dst = e.data
+	e.data = nil
+	return dst, err
+}
+
+// formatMessage prefixes body with the current time and level, as in "2006-01-02 15:04:05 - [INFO] : body"
+func formatMessage(level Level, body string) string {
+	e := getEncodeBuffer()
+	defer putEncodeBuffer(e)
+
+	e.data = appendMessagePrefix(e.data, level)
+	e.data = append(e.data, body...)
+	return string(e.data)
+}
+
+func appendMessagePrefix(dst []byte, level Level) []byte {
+	dst = time.Now().AppendFormat(dst, "2006-01-02 15:04:05")
+	dst = append(dst, " - ["...)
+	dst = append(dst, LogLevelToString(level)...)
+	return append(dst, "] : "...)
+}
+
+// appendStackTrace appends the stack of every goroutine, as ERROR and FATAL entries carry it
+func appendStackTrace(dst []byte) []byte {
+	dst = append(dst, "\n Stack trace : \n"...)
+	dst = slices.Grow(dst, maxStackTrace)
+	n := runtime.Stack(dst[len(dst):len(dst)+maxStackTrace], true)
+	return dst[:len(dst)+n]
+}
+
+// appendArgs appends the arguments of Log as Stringify does, a JSON array followed by " | ", with the values
+// encoded by appendValue rather than json.Marshal. Arguments that cannot be encoded are formatted with %v.
+func (e *encodeBuffer) appendArgs(args []interface{}) {
+	start := len(e.data)
+	if err := e.appendValue(logger.ApplyLogTags(args)); err != nil {
+		e.data = fmt.Appendf(e.data[:start], "%v ", args)
+	}
+	e.data = append(e.data, " | "...)
+}
+
+// rawSink returns the sink as an IRawLogger, nil when it only accepts a LogMessage
+func rawSink(s ILogger) IRawLogger {
+	raw, _ := unwrapSink(s).(IRawLogger)
+	return raw
+}
+
+func (e *encodeBuffer) appendLogMessage(m *LogMessage) error {
+	e.data = append(e.data, `{"timestamp":`...)
+	e.data = appendJSONString(e.data, m.Timestamp)
+	e.data = append(e.data, `,"level":`...)
+	e.data = appendJSONString(e.data, m.Level)
+	e.data = append(e.data, `,"message":`...)
+	e.data = appendJSONString(e.data, m.Message)
+	e.data = append(e.data, `,"tags":`...)
+	e.appendStringMap(m.Tags)
+
+	if len(m.Fields) > 0 {
+		e.data = append(e.data, `,"fields":`...)
+		if err := e.appendMap(m.Fields); err != nil {
+			return err
+		}
+	}
+
+	if m.TraceID != "" {
+		e.data = append(e.data, `,"trace_id":`...)
+		e.data = appendJSONString(e.data, m.TraceID)
+	}
+	if m.SpanID != "" {
+		e.data = append(e.data, `,"span_id":`...)
+		e.data = appendJSONString(e.data, m.SpanID)
+	}
+	if m.TraceFlags != "" {
+		e.data = append(e.data, `,"trace_flags":`...)
+		e.data = appendJSONString(e.data, m.TraceFlags)
+	}
+
+	e.data = append(e.data, '}')
+	return nil
+}
+
+func (e *encodeBuffer) appendStringMap(m map[string]string) {
+	if m == nil {
+		e.data = append(e.data, "null"...)
+		return
+	}
+
+	start := len(e.keys)
+	for key := range m {
+		e.keys = append(e.keys, key)
+	}
+	slices.Sort(e.keys[start:])
+
+	e.data = append(e.data, '{')
+	for i := start; i < len(e.keys); i++ {
+		if i > start {
+			e.data = append(e.data, ',')
+		}
+		e.data = appendJSONString(e.data, e.keys[i])
+		e.data = append(e.data, ':')
+		e.data = appendJSONString(e.data, m[e.keys[i]])
+	}
+	e.data = append(e.data, '}')
+
+	clear(e.keys[start:])
+	e.keys = e.keys[:start]
+}
+
+func (e *encodeBuffer) appendMap(m map[string]interface{}) error {
+	if m == nil {
+		e.data = append(e.data, "null"...)
+		return nil
+	}
+
+	start := len(e.keys)
+	for key := range m {
+		e.keys = append(e.keys, key)
+	}
+	slices.Sort(e.keys[start:])
+	// Keys are read back by index, nested maps append their own keys after end
+	end := len(e.keys)
+
+	e.data = append(e.data, '{')
+	for i := start; i < end; i++ {
+		if i > start {
+			e.data = append(e.data, ',')
+		}
+		e.data = appendJSONString(e.data, e.keys[i])
+		e.data = append(e.data, ':')
+		if err := e.appendValue(m[e.keys[i]]); err != nil {
+			return err
+		}
+	}
+	e.data = append(e.data, '}')
+
+	clear(e.keys[start:])
+	e.keys = e.keys[:start]
+	return nil
+}
+
+func (e *encodeBuffer) appendValue(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		e.data = append(e.data, "null"...)
+	case string:
+		e.data = appendJSONString(e.data, v)
+	case bool:
+		e.data = strconv.AppendBool(e.data, v)
+	case int:
+		e.data = strconv.AppendInt(e.data, int64(v), 10)
+	case int8:
+		e.data = strconv.AppendInt(e.data, int64(v), 10)
+	case int16:
+		e.data = strconv.AppendInt(e.data, int64(v), 10)
+	case int32:
+		e.data = strconv.AppendInt(e.data, int64(v), 10)
+	case int64:
+		e.data = strconv.AppendInt(e.data, v, 10)
+	case time.Duration:
+		e.data = strconv.AppendInt(e.data, int64(v), 10)
+	case uint:
+		e.data = strconv.AppendUint(e.data, uint64(v), 10)
+	case uint8:
+		e.data = strconv.AppendUint(e.data, uint64(v), 10)
+	case uint16:
+		e.data = strconv.AppendUint(e.data, uint64(v), 10)
+	case uint32:
+		e.data = strconv.AppendUint(e.data, uint64(v), 10)
+	case uint64:
+		e.data = strconv.AppendUint(e.data, v, 10)
+	case float32:
+		return e.appendFloat(float64(v), 32)
+	case float64:
+		return e.appendFloat(v, 64)
+	case map[string]string:
+		e.appendStringMap(v)
+	case map[string]interface{}:
+		return e.appendMap(v)
+	case []string:
+		if v == nil {
+			e.data = append(e.data, "null"...)
+			return nil
+		}
+		e.data = append(e.data, '[')
+		for i, s := range v {
+			if i > 0 {
+				e.data = append(e.data, ',')
+			}
+			e.data = appendJSONString(e.data, s)
+		}
+		e.data = append(e.data, ']')
+	case []interface{}:
+		if v == nil {
+			e.data = append(e.data, "null"...)
+			return nil
+		}
+		e.data = append(e.data, '[')
+		for i, item := range v {
+			if i > 0 {
+				e.data = append(e.data, ',')
+			}
+			if err := e.appendValue(item); err != nil {
+				return err
+			}
+		}
+		e.data = append(e.data, ']')
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		e.data = append(e.data, encoded...)
+	}
+	return nil
+}
+
+// appendFloat formats floats like encoding/json
+func (e *encodeBuffer) appendFloat(f float64, bits int) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return &json.UnsupportedValueError{Str: strconv.FormatFloat(f, 'g', -1, bits)}
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+
+	e.data = strconv.AppendFloat(e.data, f, format, -1, bits)
+	if format == 'e' {
+		// e-09 becomes e-9
+		n := len(e.data)
+		if n >= 4 && e.data[n-4] == 'e' && e.data[n-3] == '-' && e.data[n-2] == '0' {
+			e.data[n-2] = e.data[n-1]
+			e.data = e.data[:n-1]
+		}
+	}
+	return nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString quotes s like encoding/json, with HTML characters escaped and invalid UTF-8 replaced
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '\\', '"':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if c == '\u2028' || c == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
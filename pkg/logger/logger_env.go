@@ -0,0 +1,178 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DefaultEnvPrefix is the prefix of the variables read by FromEnv when none is given
+const DefaultEnvPrefix = "LOGGER"
+
+// FromEnv builds a configuration from the environment variables starting with prefix,
+// see ApplyEnv for the naming and parsing rules
+func FromEnv(prefix string) (*Configuration, error) {
+	config := NewConfiguration()
+	if err := config.ApplyEnv(prefix); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// ApplyEnv overrides the configuration with the environment variables starting with prefix, so a
+// configuration read from a file and then passed to ApplyEnv gives the precedence defaults < file < env.
+//
+// A variable is named after the json key of its field in upper case, LOGGER_NATS_URL for nats_url,
+// nested objects add their own key, LOGGER_SAMPLING_FIRST for sampling.first. Booleans and numbers are
+// parsed with strconv, lists are comma separated, string maps use key=value pairs
+// (LOGGER_TAGS=team=payments,env=prod) and every other type is read as json. A variable replaces the whole
+// value of its field. Every variable can also be read from the file named by the same variable with a
+// _FILE suffix, LOGGER_NATS_PASSWORD_FILE=/run/secrets/nats, setting both is an error.
+func (c *Configuration) ApplyEnv(prefix string) error {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	prefix = strings.ToUpper(strings.TrimSuffix(prefix, "_")) + "_"
+
+	_, errs := applyEnv(reflect.ValueOf(c).Elem(), prefix)
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid logger environment: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// applyEnv sets the fields of the struct v from the environment and reports whether any variable was found
+func applyEnv(v reflect.Value, prefix string) (bool, []error) {
+	var errs []error
+	found := false
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		key := prefix + strings.ToUpper(name)
+		field := v.Field(i)
+
+		value, ok, err := lookupEnv(key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if !ok {
+			// Nested objects are only allocated when one of their variables is set
+			if f.Type.Kind() == reflect.Pointer && f.Type.Elem().Kind() == reflect.Struct {
+				nested := reflect.New(f.Type.Elem())
+				if !field.IsNil() {
+					nested.Elem().Set(field.Elem())
+				}
+				nestedFound, nestedErrs := applyEnv(nested.Elem(), key+"_")
+				errs = append(errs, nestedErrs...)
+				if nestedFound {
+					field.Set(nested)
+					found = true
+				}
+			}
+			continue
+		}
+
+		found = true
+		if err := setEnvValue(field, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	return found, errs
+}
+
+// lookupEnv returns the value of the variable key, or the trimmed content of the file named by key_FILE
+func lookupEnv(key string) (string, bool, error) {
+	value, ok := os.LookupEnv(key)
+	path, fileOk := os.LookupEnv(key + "_FILE")
+
+	if !fileOk {
+		return value, ok, nil
+	}
+	if ok {
+		return "", false, fmt.Errorf("%s and %s_FILE cannot both be set", key, key)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %w", key, err)
+	}
+	return strings.TrimSpace(string(content)), true, nil
+}
+
+func setEnvValue(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		field.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		field.SetInt(n)
+
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		field.SetFloat(n)
+
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return json.Unmarshal([]byte(value), field.Addr().Interface())
+		}
+		list := reflect.MakeSlice(field.Type(), 0, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = reflect.Append(list, reflect.ValueOf(item).Convert(field.Type().Elem()))
+			}
+		}
+		field.Set(list)
+
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String || field.Type().Elem().Kind() != reflect.String {
+			return json.Unmarshal([]byte(value), field.Addr().Interface())
+		}
+		m := reflect.MakeMap(field.Type())
+		for _, pair := range strings.Split(value, ",") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			k, v, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("invalid key=value pair %q", pair)
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(k)).Convert(field.Type().Key()),
+				reflect.ValueOf(strings.TrimSpace(v)).Convert(field.Type().Elem()))
+		}
+		field.Set(m)
+
+	default:
+		return json.Unmarshal([]byte(value), field.Addr().Interface())
+	}
+
+	return nil
+}
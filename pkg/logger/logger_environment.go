@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// DefaultPodInfoPath is where the Kubernetes Downward API volume is usually mounted
+const DefaultPodInfoPath = "/etc/podinfo"
+
+// environmentTag is a tag read from the first variable set, or from a Downward API file or label
+type environmentTag struct {
+	tag    string
+	envs   []string
+	file   string
+	labels []string
+}
+
+var environmentTags = []environmentTag{
+	{tag: "pod_name", envs: []string{"POD_NAME", "K8S_POD_NAME"}, file: "name"},
+	{tag: "namespace", envs: []string{"POD_NAMESPACE", "K8S_NAMESPACE"}, file: "namespace"},
+	{tag: "node_name", envs: []string{"NODE_NAME", "K8S_NODE_NAME"}, file: "node_name"},
+	{tag: "container_name", envs: []string{"CONTAINER_NAME", "K8S_CONTAINER_NAME"}, file: "container_name"},
+	{tag: "service_name", envs: []string{"SERVICE_NAME", "OTEL_SERVICE_NAME"}, labels: []string{"app.kubernetes.io/name", "app"}},
+	{tag: "service_version", envs: []string{"SERVICE_VERSION", "APP_VERSION"}, labels: []string{"app.kubernetes.io/version", "version"}},
+}
+
+// WithEnvironmentTags adds tags describing where the service runs: pod_name, namespace, node_name,
+// container_name, service_name and service_version from the Downward API, plus module, module_version and
+// vcs_revision from the build info. podInfoPath is the Downward API volume, DefaultPodInfoPath when empty.
+func WithEnvironmentTags(podInfoPath string) LoggerOption {
+	return func(l *MultiLogger) {
+		for key, value := range EnvironmentTags(podInfoPath) {
+			l.tags[key] = value
+		}
+	}
+}
+
+// EnvironmentTags returns the tags added by WithEnvironmentTags, tags without a value are left out.
+// Environment variables take precedence over the files of the Downward API volume: name, namespace,
+// node_name, container_name and labels, where service_name and service_version are read from the
+// app.kubernetes.io/name and app.kubernetes.io/version labels.
+func EnvironmentTags(podInfoPath string) map[string]string {
+	if podInfoPath == "" {
+		podInfoPath = DefaultPodInfoPath
+	}
+
+	tags := make(map[string]string)
+	labels := readPodInfoLabels(filepath.Join(podInfoPath, "labels"))
+
+	for _, t := range environmentTags {
+		if value := environmentTagValue(t, podInfoPath, labels); value != "" {
+			tags[t.tag] = value
+		}
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Path != "" {
+			tags["module"] = info.Main.Path
+		}
+		if info.Main.Version != "" && info.Main.Version != "(devel)" {
+			tags["module_version"] = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" && setting.Value != "" {
+				tags["vcs_revision"] = setting.Value
+			}
+		}
+	}
+
+	return tags
+}
+
+func environmentTagValue(t environmentTag, podInfoPath string, labels map[string]string) string {
+	for _, name := range t.envs {
+		if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+			return value
+		}
+	}
+
+	if t.file != "" {
+		if content, err := os.ReadFile(filepath.Join(podInfoPath, t.file)); err == nil {
+			if value := strings.TrimSpace(string(content)); value != "" {
+				return value
+			}
+		}
+	}
+
+	for _, label := range t.labels {
+		if value := labels[label]; value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// readPodInfoLabels parses the Downward API labels file, one key="value" pair per line
+func readPodInfoLabels(path string) map[string]string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	labels := make(map[string]string)
+	for _, line := range strings.Split(string(content), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		labels[key] = value
+	}
+	return labels
+}
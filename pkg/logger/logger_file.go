@@ -0,0 +1,197 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultWatchInterval is how often a watched configuration file is checked for changes
+const DefaultWatchInterval = 5 * time.Second
+
+// FromFile reads a configuration file, the format is chosen by the extension: .json, .yaml, .yml or .toml.
+// Every format uses the json keys of Configuration.
+func FromFile(path string) (*Configuration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FromJsonString(string(data))
+	case ".yaml", ".yml":
+		return FromYamlString(string(data))
+	case ".toml":
+		return FromTomlString(string(data))
+	default:
+		return nil, fmt.Errorf("unsupported configuration format %q", filepath.Ext(path))
+	}
+}
+
+// FromYamlString parses a YAML configuration, unknown fields are kept to be reported by Validate
+func FromYamlString(yamlString string) (*Configuration, error) {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal([]byte(yamlString), &raw); err != nil {
+		return nil, err
+	}
+	return fromRawConfiguration(raw)
+}
+
+// FromTomlString parses a TOML configuration, unknown fields are kept to be reported by Validate
+func FromTomlString(tomlString string) (*Configuration, error) {
+	var raw map[string]interface{}
+	if err := toml.Unmarshal([]byte(tomlString), &raw); err != nil {
+		return nil, err
+	}
+	return fromRawConfiguration(raw)
+}
+
+// fromRawConfiguration goes through json so that every format shares the keys and checks of FromJsonString
+func fromRawConfiguration(raw map[string]interface{}) (*Configuration, error) {
+	if raw == nil {
+		return NewConfiguration(), nil
+	}
+
+	jsonBytes, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	return FromJsonString(string(jsonBytes))
+}
+
+// WatchOptions configures a ConfigWatcher
+type WatchOptions struct {
+	Interval time.Duration
+
+	// EnvPrefix applies the environment variables with this prefix on top of every reloaded file
+	EnvPrefix string
+
+	// IgnoreSIGHUP disables the reload when the process receives SIGHUP
+	IgnoreSIGHUP bool
+}
+
+// ConfigWatcher reloads a configuration file into a running logger
+type ConfigWatcher struct {
+	logger  *MultiLogger
+	path    string
+	options WatchOptions
+
+	hash     [sha256.Size]byte
+	mutex    sync.Mutex
+	quit     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewConfigWatcher reloads the configuration file at path into l whenever its content changes or the process
+// receives SIGHUP. The file is polled rather than watched for events, so Kubernetes ConfigMap updates, which
+// swap a symlink, are picked up like any other change. A configuration that fails to load is logged and the
+// current one is kept.
+func NewConfigWatcher(l *MultiLogger, path string, options WatchOptions) *ConfigWatcher {
+	if options.Interval <= 0 {
+		options.Interval = DefaultWatchInterval
+	}
+
+	w := &ConfigWatcher{
+		logger:  l,
+		path:    path,
+		options: options,
+		quit:    make(chan struct{}),
+	}
+
+	if data, err := os.ReadFile(path); err == nil {
+		w.hash = sha256.Sum256(data)
+	}
+
+	go w.run()
+	return w
+}
+
+func (w *ConfigWatcher) run() {
+	ticker := time.NewTicker(w.options.Interval)
+	defer ticker.Stop()
+
+	signals := make(chan os.Signal, 1)
+	if !w.options.IgnoreSIGHUP {
+		signal.Notify(signals, syscall.SIGHUP)
+		defer signal.Stop(signals)
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			w.reload(false)
+		case <-signals:
+			w.reload(true)
+		case <-w.quit:
+			return
+		case <-w.logger.quitLogCh:
+			return
+		}
+	}
+}
+
+// Reload reads and applies the configuration file now, whether it changed or not
+func (w *ConfigWatcher) Reload() error {
+	return w.reload(true)
+}
+
+// reload applies the file if forced or if its content changed, a failed change is logged once
+func (w *ConfigWatcher) reload(force bool) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		if force {
+			w.logger.logInternal(ERROR, "Failed to reload logger configuration from %s: %v", w.path, err)
+		}
+		return err
+	}
+
+	hash := sha256.Sum256(data)
+	if !force && hash == w.hash {
+		return nil
+	}
+	w.hash = hash
+
+	if err := w.apply(); err != nil {
+		w.logger.logInternal(ERROR, "Failed to reload logger configuration from %s: %v", w.path, err)
+		return err
+	}
+
+	w.logger.logInternal(INFO, "Reloaded logger configuration from %s", w.path)
+	return nil
+}
+
+func (w *ConfigWatcher) apply() error {
+	config, err := FromFile(w.path)
+	if err != nil {
+		return err
+	}
+
+	if w.options.EnvPrefix != "" {
+		if err := config.ApplyEnv(w.options.EnvPrefix); err != nil {
+			return err
+		}
+	}
+
+	return w.logger.Reconfigure(config)
+}
+
+// Stop stops watching, the logger keeps its current configuration
+func (w *ConfigWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.quit)
+	})
+}
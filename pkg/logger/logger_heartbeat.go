@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DefaultHeartbeatSubject is the subject heartbeats are published on when none is configured
+const DefaultHeartbeatSubject = "logs.heartbeat"
+
+// DefaultHeartbeatInterval is the interval between heartbeats when none is configured
+const DefaultHeartbeatInterval = 30 * time.Second
+
+// IPublisher publishes raw payloads on a subject, NATS implements it
+type IPublisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// HeartbeatOptions configures the periodic heartbeat of a MultiLogger
+type HeartbeatOptions struct {
+	Service  string
+	Version  string
+	Subject  string
+	Interval time.Duration
+}
+
+// Heartbeat is the message published periodically so collectors can detect silent services
+type Heartbeat struct {
+	Timestamp          string          `json:"timestamp"`
+	Service            string          `json:"service"`
+	Hostname           string          `json:"hostname"`
+	Version            string          `json:"version"`
+	AliveSince         string          `json:"alive_since"`
+	UptimeSeconds      int64           `json:"uptime_seconds"`
+	TotalMessages      int64           `json:"total_messages"`
+	DroppedMessages    int64           `json:"dropped_messages"`
+	SuppressedMessages int64           `json:"suppressed_messages"`
+	LoggerFailed       int64           `json:"logger_failed"`
+	LastLoggerFailed   string          `json:"last_logger_failed,omitempty"`
+	Sinks              []HeartbeatSink `json:"sinks"`
+	Stopping           bool            `json:"stopping,omitempty"`
+}
+
+// HeartbeatSink holds the counters of a single sink in a Heartbeat
+type HeartbeatSink struct {
+	Name      string `json:"name"`
+	Messages  int64  `json:"messages"`
+	Errors    int64  `json:"errors"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+type heartbeat struct {
+	publisher IPublisher
+	options   HeartbeatOptions
+}
+
+// WithHeartbeat publishes a heartbeat with the logger metrics through publisher at a fixed interval
+func WithHeartbeat(publisher IPublisher, options HeartbeatOptions) LoggerOption {
+	return func(l *MultiLogger) {
+		if options.Subject == "" {
+			options.Subject = DefaultHeartbeatSubject
+		}
+		if options.Interval <= 0 {
+			options.Interval = DefaultHeartbeatInterval
+		}
+		l.heartbeat = &heartbeat{publisher: publisher, options: options}
+	}
+}
+
+func (l *MultiLogger) startHeartbeat() {
+	ticker := time.NewTicker(l.heartbeat.options.Interval)
+	defer ticker.Stop()
+
+	l.publishHeartbeat(false)
+	for {
+		select {
+		case <-ticker.C:
+			l.publishHeartbeat(false)
+		case <-l.quitLogCh:
+			l.publishHeartbeat(true)
+			return
+		}
+	}
+}
+
+func (l *MultiLogger) publishHeartbeat(stopping bool) {
+	jsonBytes, err := json.Marshal(l.buildHeartbeat(stopping))
+	if err != nil {
+		fallbackLog(ERROR, fmt.Sprintln("Error encoding heartbeat: ", err))
+		return
+	}
+
+	l.mutex.RLock()
+	publisher := l.heartbeat.publisher
+	l.mutex.RUnlock()
+
+	if err := publisher.Publish(l.heartbeat.options.Subject, jsonBytes); err != nil {
+		fallbackLog(ERROR, fmt.Sprintln("Error publishing heartbeat: ", err))
+	}
+}
+
+func (l *MultiLogger) buildHeartbeat(stopping bool) Heartbeat {
+	metrics := l.Metrics()
+	now := time.Now()
+
+	l.mutex.RLock()
+	hostname := l.tags["hostname"]
+	l.mutex.RUnlock()
+
+	hb := Heartbeat{
+		Timestamp:          now.Format(time.RFC3339),
+		Service:            l.heartbeat.options.Service,
+		Hostname:           hostname,
+		Version:            l.heartbeat.options.Version,
+		AliveSince:         metrics.AliveSince.Format(time.RFC3339),
+		UptimeSeconds:      int64(now.Sub(metrics.AliveSince).Seconds()),
+		TotalMessages:      metrics.ChTotalMessages,
+		DroppedMessages:    metrics.ChDroppedMessages,
+		SuppressedMessages: metrics.SuppressedMessages,
+		LoggerFailed:       metrics.LoggerFailedCount,
+		Sinks:              make([]HeartbeatSink, 0, len(metrics.Sinks)),
+		Stopping:           stopping,
+	}
+	if metrics.LoggerFailedCount > 0 {
+		hb.LastLoggerFailed = metrics.LastLoggerFailed.Format(time.RFC3339)
+	}
+
+	for _, s := range metrics.Sinks {
+		hb.Sinks = append(hb.Sinks, HeartbeatSink{
+			Name:      s.Name,
+			Messages:  s.Messages,
+			Errors:    s.Errors,
+			LastError: s.LastError,
+		})
+	}
+	return hb
+}
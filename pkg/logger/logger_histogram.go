@@ -0,0 +1,174 @@
+package logger
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the histogram upper bounds used when no buckets are configured
+var DefaultLatencyBuckets = []time.Duration{
+	time.Microsecond,
+	5 * time.Microsecond,
+	10 * time.Microsecond,
+	25 * time.Microsecond,
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Histogram records durations with nanosecond resolution into fixed buckets
+type Histogram struct {
+	bounds []int64
+	counts []int64 // len(bounds)+1, the last bucket holds values above the highest bound
+	count  int64
+	sum    int64
+	min    int64
+	max    int64
+
+	mutex sync.Mutex
+}
+
+// HistogramSnapshot is an immutable copy of a Histogram
+type HistogramSnapshot struct {
+	Buckets []time.Duration // upper bounds, inclusive
+	Counts  []int64         // len(Buckets)+1, the last entry counts values above the highest bound
+	Count   int64
+	Sum     time.Duration
+	Min     time.Duration
+	Max     time.Duration
+}
+
+// NewHistogram creates a histogram with the given bucket upper bounds, DefaultLatencyBuckets if none are given
+func NewHistogram(buckets ...time.Duration) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+
+	bounds := make([]int64, 0, len(buckets))
+	for _, b := range buckets {
+		bounds = append(bounds, int64(b))
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+
+	return &Histogram{
+		bounds: bounds,
+		counts: make([]int64, len(bounds)+1),
+	}
+}
+
+// Observe records a single duration
+func (h *Histogram) Observe(d time.Duration) {
+	v := int64(d)
+	i := sort.Search(len(h.bounds), func(i int) bool { return h.bounds[i] >= v })
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.counts[i] += 1
+	if h.count == 0 || v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+	h.count += 1
+	h.sum += v
+}
+
+// Reset clears all recorded values, keeping the buckets
+func (h *Histogram) Reset() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for i := range h.counts {
+		h.counts[i] = 0
+	}
+	h.count = 0
+	h.sum = 0
+	h.min = 0
+	h.max = 0
+}
+
+// Snapshot returns a copy of the histogram that is safe to read without locking
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	buckets := make([]time.Duration, len(h.bounds))
+	for i, b := range h.bounds {
+		buckets[i] = time.Duration(b)
+	}
+
+	counts := make([]int64, len(h.counts))
+	copy(counts, h.counts)
+
+	return HistogramSnapshot{
+		Buckets: buckets,
+		Counts:  counts,
+		Count:   h.count,
+		Sum:     time.Duration(h.sum),
+		Min:     time.Duration(h.min),
+		Max:     time.Duration(h.max),
+	}
+}
+
+// Mean returns the average of all recorded values
+func (s HistogramSnapshot) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / time.Duration(s.Count)
+}
+
+// Quantile estimates the q-th quantile (0 <= q <= 1) by interpolating linearly inside the matching bucket
+func (s HistogramSnapshot) Quantile(q float64) time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return s.Min
+	}
+	if q >= 1 {
+		return s.Max
+	}
+
+	rank := q * float64(s.Count)
+	var seen int64
+	for i, c := range s.Counts {
+		if c == 0 {
+			continue
+		}
+		if float64(seen+c) < rank {
+			seen += c
+			continue
+		}
+
+		lower := s.Min
+		if i > 0 && s.Buckets[i-1] > lower {
+			lower = s.Buckets[i-1]
+		}
+		upper := s.Max
+		if i < len(s.Buckets) && s.Buckets[i] < upper {
+			upper = s.Buckets[i]
+		}
+
+		fraction := (rank - float64(seen)) / float64(c)
+		return lower + time.Duration(fraction*float64(upper-lower))
+	}
+	return s.Max
+}
@@ -0,0 +1,227 @@
+package logger
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// DefaultRequestIDHeader is the header carrying the request ID
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// HTTPOptions configures HTTPMiddleware
+type HTTPOptions struct {
+	// RequestIDHeader is read and set on the response, DefaultRequestIDHeader when empty
+	RequestIDHeader string
+
+	// StatusLevel returns the level of the access log entry, HTTPStatusLevel when nil
+	StatusLevel func(status int) Level
+
+	// Canonical makes the access log entry the canonical line of the request, the fields added along the
+	// request path with Canonical(ctx) are logged with it and its level is raised by RaiseLevel
+	Canonical bool
+
+	// BufferedEntries holds up to this many DEBUG and INFO entries of each request in a Scope, they are only
+	// logged if the request fails with a panic, an ERROR entry or a server error status
+	BufferedEntries int
+}
+
+// HTTPStatusLevel logs server errors at ERROR, client errors at WARN and everything else at INFO
+func HTTPStatusLevel(status int) Level {
+	switch {
+	case status >= 500:
+		return ERROR
+	case status >= 400:
+		return WARN
+	default:
+		return INFO
+	}
+}
+
+// HTTPMiddleware logs one access log entry per request with its status, bytes and duration and recovers
+// panics, which are logged at ERROR with their stack and mark the access log entry with panicked. The request
+// ID is taken from the request header or generated, and set on the response. The request context carries l
+// and the request_id, method, path, remote_addr and user_agent fields, so handlers log with them through
+// FromContext and LogContext, or the LogContextf method of IContextLogger. The access log also carries the
+// route when the request was served by an http.ServeMux pattern.
+func HTTPMiddleware(l *MultiLogger, options HTTPOptions) func(http.Handler) http.Handler {
+	if options.RequestIDHeader == "" {
+		options.RequestIDHeader = DefaultRequestIDHeader
+	}
+	if options.StatusLevel == nil {
+		options.StatusLevel = HTTPStatusLevel
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := r.Header.Get(options.RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+			}
+			w.Header().Set(options.RequestIDHeader, requestID)
+
+			ctx := WithContextFields(NewContext(r.Context(), l),
+				"request_id", requestID,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"remote_addr", r.RemoteAddr,
+				"user_agent", r.UserAgent(),
+			)
+			var line *CanonicalLine
+			if options.Canonical {
+				ctx, line = NewCanonicalContext(ctx, r.Method+" "+r.URL.Path)
+			}
+			var scope *Scope
+			if options.BufferedEntries > 0 {
+				ctx, scope = NewScopeContext(ctx, options.BufferedEntries)
+			}
+			r = r.WithContext(ctx)
+			rw := &responseWriter{ResponseWriter: w}
+
+			defer func() {
+				panicked := false
+				if recovered := recover(); recovered != nil {
+					// ErrAbortHandler aborts the response on purpose and is handled by the server
+					if recovered == http.ErrAbortHandler {
+						panic(recovered)
+					}
+
+					panicked = true
+					l.LogContextf(ERROR, ctx, "Panic serving %s %s: %v\n Stack trace : \n%s", r.Method, r.URL.Path, recovered, debug.Stack())
+					// Once the header is sent the client keeps the status it received, it is logged as is
+					if !rw.wroteHeader && !rw.hijacked {
+						http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+					}
+				}
+
+				status := rw.status
+				if status == 0 {
+					status = http.StatusOK
+				}
+				duration := time.Since(start)
+
+				fields := []interface{}{
+					"status", status,
+					"bytes", rw.bytes,
+					"duration_ms", float64(duration.Microseconds()) / 1000,
+				}
+				if r.Pattern != "" {
+					fields = append(fields, "route", r.Pattern)
+				}
+				if panicked {
+					fields = append(fields, "panicked", true)
+				}
+				if rw.hijacked {
+					fields = append(fields, "hijacked", true)
+				}
+
+				// The access log entry is not held by the scope of the request
+				if scope != nil {
+					if status >= 500 || panicked {
+						scope.Flush()
+					}
+					scope.End()
+				}
+
+				level := options.StatusLevel(status)
+				if panicked && level < ERROR {
+					level = ERROR
+				}
+				if lineLevel, lineFields, ok := line.finish(); ok {
+					fields = append(lineFields, fields...)
+					if lineLevel > level {
+						level = lineLevel
+					}
+				}
+
+				l.LogContextf(level, WithContextFields(ctx, fields...),
+					"%s %s %d %dB %s", r.Method, r.URL.Path, status, rw.bytes, duration)
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// responseWriter records the status and the number of bytes written
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+	hijacked    bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush keeps streaming responses working behind the middleware
+func (w *responseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack keeps websocket and h2c upgrades working behind the middleware, the connection is then no longer
+// written through w and the access log entry reports the status and bytes written before
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T does not implement http.Hijacker: %w", w.ResponseWriter, http.ErrNotSupported)
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// validRequestID accepts incoming IDs of printable ASCII up to 128 characters
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
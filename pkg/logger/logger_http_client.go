@@ -0,0 +1,166 @@
+package logger
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/internal/logger"
+)
+
+// DefaultRedactedHeaders are the request headers whose values are never logged
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+}
+
+// TransportOptions configures NewTransport
+type TransportOptions struct {
+	// RequestIDHeader is set from the request_id context field, DefaultRequestIDHeader when empty
+	RequestIDHeader string
+
+	// StatusLevel returns the level of the entry of a call, ClientStatusLevel when nil
+	StatusLevel func(status int, err error) Level
+
+	// LogHeaders adds the request headers to the entries
+	LogHeaders bool
+
+	// RedactedHeaders and RedactedQueryParams are matched case-insensitively, DefaultRedactedHeaders and
+	// DefaultRedactedFields when nil
+	RedactedHeaders     []string
+	RedactedQueryParams []string
+}
+
+// ClientStatusLevel logs failed calls at ERROR, server errors at WARN, client errors at INFO and everything
+// else at DEBUG
+func ClientStatusLevel(status int, err error) Level {
+	switch {
+	case err != nil:
+		return ERROR
+	case status >= 500:
+		return WARN
+	case status >= 400:
+		return INFO
+	default:
+		return DEBUG
+	}
+}
+
+type loggingTransport struct {
+	logger          *MultiLogger
+	next            http.RoundTripper
+	options         TransportOptions
+	redactedHeaders map[string]bool
+	redactedParams  map[string]bool
+}
+
+// NewTransport wraps next, http.DefaultTransport when nil, and logs every outbound call with its method, host,
+// path, query, status, duration and error, the duration ends when the response headers are received. The
+// request_id field of the request context is sent in the request ID header unless the request already has one.
+func NewTransport(l *MultiLogger, next http.RoundTripper, options TransportOptions) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if options.RequestIDHeader == "" {
+		options.RequestIDHeader = DefaultRequestIDHeader
+	}
+	if options.StatusLevel == nil {
+		options.StatusLevel = ClientStatusLevel
+	}
+	if options.RedactedHeaders == nil {
+		options.RedactedHeaders = DefaultRedactedHeaders
+	}
+	if options.RedactedQueryParams == nil {
+		options.RedactedQueryParams = DefaultRedactedFields
+	}
+
+	return &loggingTransport{
+		logger:          l,
+		next:            next,
+		options:         options,
+		redactedHeaders: lowerSet(options.RedactedHeaders),
+		redactedParams:  lowerSet(options.RedactedQueryParams),
+	}
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if requestID, ok := ContextFields(ctx)["request_id"].(string); ok && requestID != "" && req.Header.Get(t.options.RequestIDHeader) == "" {
+		req = req.Clone(ctx)
+		req.Header.Set(t.options.RequestIDHeader, requestID)
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	duration := time.Since(start)
+
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+
+	level := t.options.StatusLevel(status, err)
+	if !t.logger.IsEnabled(level) {
+		return resp, err
+	}
+
+	fields := []interface{}{
+		"method", req.Method,
+		"host", req.URL.Host,
+		"path", req.URL.Path,
+		"duration_ms", float64(duration.Microseconds()) / 1000,
+	}
+	if req.URL.RawQuery != "" {
+		fields = append(fields, "query", t.redactQuery(req.URL.Query()))
+	}
+	if t.options.LogHeaders {
+		fields = append(fields, "headers", t.redactHeaders(req.Header))
+	}
+
+	if err != nil {
+		fields = append(fields, "error", err.Error())
+		t.logger.LogContextf(level, WithContextFields(ctx, fields...), "%s %s%s failed after %s: %v", req.Method, req.URL.Host, req.URL.Path, duration, err)
+		return resp, err
+	}
+
+	fields = append(fields, "status", status)
+	t.logger.LogContextf(level, WithContextFields(ctx, fields...), "%s %s%s %d %s", req.Method, req.URL.Host, req.URL.Path, status, duration)
+	return resp, err
+}
+
+func (t *loggingTransport) redactQuery(query url.Values) string {
+	for key, values := range query {
+		if t.redactedParams[strings.ToLower(key)] {
+			for i := range values {
+				values[i] = logger.RedactedValue
+			}
+		}
+	}
+	return query.Encode()
+}
+
+func (t *loggingTransport) redactHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for key, values := range header {
+		if t.redactedHeaders[strings.ToLower(key)] {
+			headers[key] = logger.RedactedValue
+		} else {
+			headers[key] = strings.Join(values, ", ")
+		}
+	}
+	return headers
+}
+
+func lowerSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[strings.ToLower(name)] = true
+	}
+	return set
+}
@@ -0,0 +1,214 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// DefaultKVBucket is the JetStream key-value bucket holding logger configurations
+	DefaultKVBucket = "logger-config"
+	// DefaultKVKey is the key used by every service without a key of its own
+	DefaultKVKey = "default"
+)
+
+// KVOptions selects the configuration of a service in a JetStream key-value bucket. The entry stored under Key,
+// usually the service name, is used when it exists, otherwise the one stored under DefaultKey. Entries are
+// JSON or YAML documents with the keys of Configuration.
+type KVOptions struct {
+	Bucket     string
+	Key        string
+	DefaultKey string
+}
+
+func (o KVOptions) withDefaults() KVOptions {
+	if o.Bucket == "" {
+		o.Bucket = DefaultKVBucket
+	}
+	if o.DefaultKey == "" {
+		o.DefaultKey = DefaultKVKey
+	}
+	return o
+}
+
+// keys returns the keys to read in order of preference
+func (o KVOptions) keys() []string {
+	if o.Key == "" || o.Key == o.DefaultKey {
+		return []string{o.DefaultKey}
+	}
+	return []string{o.Key, o.DefaultKey}
+}
+
+// FromKV reads a configuration from a JetStream key-value bucket
+func FromKV(nc *nats.Conn, options KVOptions) (*Configuration, error) {
+	options = options.withDefaults()
+
+	kv, err := openKV(nc, options.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range options.keys() {
+		entry, err := kv.Get(key)
+		if errors.Is(err, nats.ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s/%s: %w", options.Bucket, key, err)
+		}
+		return parseKVEntry(entry)
+	}
+
+	return nil, fmt.Errorf("no logger configuration under %s in bucket %s", strings.Join(options.keys(), " or "), options.Bucket)
+}
+
+func openKV(nc *nats.Conn, bucket string) (nats.KeyValue, error) {
+	js, err := nc.JetStream()
+	if err != nil {
+		return nil, err
+	}
+
+	kv, err := js.KeyValue(bucket)
+	if err != nil {
+		return nil, fmt.Errorf("opening bucket %s: %w", bucket, err)
+	}
+	return kv, nil
+}
+
+func parseKVEntry(entry nats.KeyValueEntry) (*Configuration, error) {
+	value := strings.TrimSpace(string(entry.Value()))
+
+	var config *Configuration
+	var err error
+	if strings.HasPrefix(value, "{") {
+		config, err = FromJsonString(value)
+	} else {
+		config, err = FromYamlString(value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s/%s revision %d: %w", entry.Bucket(), entry.Key(), entry.Revision(), err)
+	}
+	return config, nil
+}
+
+// KVWatcher applies the configuration stored in a JetStream key-value bucket to a running logger whenever it
+// changes
+type KVWatcher struct {
+	logger  *MultiLogger
+	options KVOptions
+	watcher nats.KeyWatcher
+
+	entries  map[string]nats.KeyValueEntry
+	applied  string // bucket/key@revision of the configuration in use
+	stopOnce sync.Once
+}
+
+// NewKVWatcher watches the entries selected by options and applies the preferred one to l, falling back to
+// the default key when the service key is deleted. Each configuration is applied at once with Reconfigure and
+// its origin is logged. Entries without sinks keep the sinks of the logger, so a fleet wide entry only needs
+// to hold levels, sampling and routing. Invalid entries are logged and the current configuration is kept.
+func NewKVWatcher(l *MultiLogger, nc *nats.Conn, options KVOptions) (*KVWatcher, error) {
+	options = options.withDefaults()
+
+	kv, err := openKV(nc, options.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := kv.WatchFiltered(options.keys())
+	if err != nil {
+		return nil, fmt.Errorf("watching bucket %s: %w", options.Bucket, err)
+	}
+
+	w := &KVWatcher{
+		logger:  l,
+		options: options,
+		watcher: watcher,
+		entries: make(map[string]nats.KeyValueEntry),
+	}
+
+	go w.run()
+	return w, nil
+}
+
+func (w *KVWatcher) run() {
+	initialized := false
+
+	for {
+		select {
+		case entry, ok := <-w.watcher.Updates():
+			if !ok {
+				return
+			}
+
+			// A nil entry marks the end of the current values, updates follow
+			if entry == nil {
+				initialized = true
+				w.apply()
+				continue
+			}
+
+			if entry.Operation() == nats.KeyValuePut {
+				w.entries[entry.Key()] = entry
+			} else {
+				delete(w.entries, entry.Key())
+			}
+
+			if initialized {
+				w.apply()
+			}
+		case <-w.logger.quitLogCh:
+			w.Stop()
+			return
+		}
+	}
+}
+
+// apply reconfigures the logger with the preferred entry if it is not the one in use
+func (w *KVWatcher) apply() {
+	var entry nats.KeyValueEntry
+	for _, key := range w.options.keys() {
+		if e, ok := w.entries[key]; ok {
+			entry = e
+			break
+		}
+	}
+
+	if entry == nil {
+		if w.applied != "" {
+			w.logger.logInternal(WARN, "Logger configuration %s was deleted from NATS KV, keeping it", w.applied)
+			w.applied = ""
+		}
+		return
+	}
+
+	origin := fmt.Sprintf("%s/%s@%d", entry.Bucket(), entry.Key(), entry.Revision())
+	if origin == w.applied {
+		return
+	}
+
+	config, err := parseKVEntry(entry)
+	if err == nil {
+		err = w.logger.Reconfigure(config)
+	}
+	if err != nil {
+		w.logger.logInternal(ERROR, "Failed to apply logger configuration from NATS KV %s: %v", origin, err)
+		return
+	}
+
+	w.applied = origin
+	w.logger.logInternal(INFO, "Applied logger configuration from NATS KV %s", origin)
+}
+
+// Stop stops watching, the logger keeps its current configuration
+func (w *KVWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		if err := w.watcher.Stop(); err != nil {
+			fallbackLog(ERROR, fmt.Sprintln("Error stopping NATS KV watcher: ", err))
+		}
+	})
+}
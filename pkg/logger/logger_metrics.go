@@ -11,9 +11,12 @@ type Metrics struct {
 	ChCurrentUsage int64
 	ChPeakUsage    int64
 
-	ChDroppedMessages   int64
-	ChProcessedMessages int64
-	ChTotalMessages     int64
+	ChDroppedMessages        int64
+	ChProcessedMessages      int64
+	ChTotalMessages          int64
+	SuppressedMessages       int64 // dropped by sampling or rate limiting before being enqueued
+	DeduplicatedMessages     int64 // collapsed into a repeat summary before being enqueued
+	ProcessorDroppedMessages int64 // dropped by a processor before reaching the sinks
 
 	ChMessageProcessingTimeMsAvg int64 // not valid until 100 messages processed
 	ChMessageProcessingTimeMsMax int64
@@ -28,9 +31,196 @@ type Metrics struct {
 	FatalCount   int64
 	UnknownCount int64
 
+	// EnqueueLatency records the time from enqueueing an entry until the sinks have written it, once per entry
+	EnqueueLatency *Histogram
+
 	mutex sync.Mutex
 }
 
+// MetricsSnapshot is an immutable copy of the logger metrics
+type MetricsSnapshot struct {
+	AliveSince time.Time
+
+	ChCurrentUsage int64
+	ChPeakUsage    int64
+
+	ChDroppedMessages        int64
+	ChProcessedMessages      int64
+	ChTotalMessages          int64
+	SuppressedMessages       int64
+	DeduplicatedMessages     int64
+	ProcessorDroppedMessages int64
+
+	ChMessageProcessingTimeMsAvg int64
+	ChMessageProcessingTimeMsMax int64
+
+	LoggerFailedCount int64
+	LastLoggerFailed  time.Time
+
+	DebugCount   int64
+	InfoCount    int64
+	WarnCount    int64
+	ErrorCount   int64
+	FatalCount   int64
+	UnknownCount int64
+
+	EnqueueLatency HistogramSnapshot
+	Sinks          []SinkMetricsSnapshot
+}
+
+// SinkMetricsSnapshot holds the metrics of a single sink
+type SinkMetricsSnapshot struct {
+	Name          string
+	Messages      int64
+	Bytes         int64 // bytes handed to the sink, the encoded entry for IRawLogger sinks and the message otherwise
+	Errors        int64
+	LastError     string
+	LastErrorTime time.Time
+	WriteLatency  HistogramSnapshot
+}
+
+// sinkMetrics holds the counters of a single sink
+type sinkMetrics struct {
+	messages      int64
+	bytes         int64
+	errors        int64
+	lastError     string
+	lastErrorTime time.Time
+
+	mutex sync.Mutex
+}
+
+// WithLatencyBuckets sets the bucket upper bounds of the latency histograms
+func WithLatencyBuckets(buckets ...time.Duration) LoggerOption {
+	return func(l *MultiLogger) {
+		l.latencyBuckets = buckets
+	}
+}
+
+// Metrics returns a copy of the logger metrics that is safe to read while the logger is running
+func (l *MultiLogger) Metrics() MetricsSnapshot {
+	snapshot := l.metrics.snapshot()
+
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	for _, s := range l.sinks {
+		snapshot.Sinks = append(snapshot.Sinks, s.snapshot())
+	}
+	return snapshot
+}
+
+// MetricsSnapshot returns a copy of the logger metrics.
+//
+// Deprecated: use Metrics.
+func (l *MultiLogger) MetricsSnapshot() MetricsSnapshot {
+	return l.Metrics()
+}
+
+// ResetMetrics clears all counters and histograms, AliveSince is kept
+func (l *MultiLogger) ResetMetrics() {
+	l.metrics.reset()
+
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	for _, s := range l.sinks {
+		s.reset()
+	}
+}
+
+func (m *Metrics) snapshot() MetricsSnapshot {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return MetricsSnapshot{
+		AliveSince:                   m.AliveSince,
+		ChCurrentUsage:               m.ChCurrentUsage,
+		ChPeakUsage:                  m.ChPeakUsage,
+		ChDroppedMessages:            m.ChDroppedMessages,
+		ChProcessedMessages:          m.ChProcessedMessages,
+		ChTotalMessages:              m.ChTotalMessages,
+		SuppressedMessages:           m.SuppressedMessages,
+		DeduplicatedMessages:         m.DeduplicatedMessages,
+		ProcessorDroppedMessages:     m.ProcessorDroppedMessages,
+		ChMessageProcessingTimeMsAvg: m.ChMessageProcessingTimeMsAvg,
+		ChMessageProcessingTimeMsMax: m.ChMessageProcessingTimeMsMax,
+		LoggerFailedCount:            m.LoggerFailedCount,
+		LastLoggerFailed:             m.LastLoggerFailed,
+		DebugCount:                   m.DebugCount,
+		InfoCount:                    m.InfoCount,
+		WarnCount:                    m.WarnCount,
+		ErrorCount:                   m.ErrorCount,
+		FatalCount:                   m.FatalCount,
+		UnknownCount:                 m.UnknownCount,
+		EnqueueLatency:               m.EnqueueLatency.Snapshot(),
+	}
+}
+
+func (m *Metrics) reset() {
+	m.mutex.Lock()
+	m.ChCurrentUsage = 0
+	m.ChPeakUsage = 0
+	m.ChDroppedMessages = 0
+	m.ChProcessedMessages = 0
+	m.ChTotalMessages = 0
+	m.SuppressedMessages = 0
+	m.DeduplicatedMessages = 0
+	m.ProcessorDroppedMessages = 0
+	m.ChMessageProcessingTimeMsAvg = 0
+	m.ChMessageProcessingTimeMsMax = 0
+	m.LoggerFailedCount = 0
+	m.LastLoggerFailed = time.Time{}
+	m.DebugCount = 0
+	m.InfoCount = 0
+	m.WarnCount = 0
+	m.ErrorCount = 0
+	m.FatalCount = 0
+	m.UnknownCount = 0
+	m.mutex.Unlock()
+
+	m.EnqueueLatency.Reset()
+}
+
+func (s *sink) recordWrite(bytes int, err error) {
+	s.metrics.mutex.Lock()
+	defer s.metrics.mutex.Unlock()
+
+	if err != nil {
+		s.metrics.errors += 1
+		s.metrics.lastError = err.Error()
+		s.metrics.lastErrorTime = time.Now()
+		return
+	}
+	s.metrics.messages += 1
+	s.metrics.bytes += int64(bytes)
+}
+
+func (s *sink) snapshot() SinkMetricsSnapshot {
+	s.metrics.mutex.Lock()
+	defer s.metrics.mutex.Unlock()
+
+	return SinkMetricsSnapshot{
+		Name:          s.name,
+		Messages:      s.metrics.messages,
+		Bytes:         s.metrics.bytes,
+		Errors:        s.metrics.errors,
+		LastError:     s.metrics.lastError,
+		LastErrorTime: s.metrics.lastErrorTime,
+		WriteLatency:  s.latency.Snapshot(),
+	}
+}
+
+func (s *sink) reset() {
+	s.metrics.mutex.Lock()
+	s.metrics.messages = 0
+	s.metrics.bytes = 0
+	s.metrics.errors = 0
+	s.metrics.lastError = ""
+	s.metrics.lastErrorTime = time.Time{}
+	s.metrics.mutex.Unlock()
+
+	s.latency.Reset()
+}
+
 func (m *Metrics) LevelCountInc(level Level) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
@@ -85,6 +275,24 @@ func (m *Metrics) ChTotalMessagesInc() {
 	m.ChTotalMessages += 1
 }
 
+func (m *Metrics) SuppressedMessagesInc() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.SuppressedMessages += 1
+}
+
+func (m *Metrics) DeduplicatedMessagesInc() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.DeduplicatedMessages += 1
+}
+
+func (m *Metrics) ProcessorDroppedMessagesInc() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.ProcessorDroppedMessages += 1
+}
+
 func (m *Metrics) ChMessageProcessingTimeMsAvgAdd(processingTimeMs int64) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
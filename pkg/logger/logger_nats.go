@@ -14,6 +14,10 @@ type NATS struct {
 	conn        *nats.Conn
 	subject     string
 	clientID    string
+	name        string
+
+	retryOnFailedConnect bool
+	reconnectWait        time.Duration
 }
 
 // NATSOption is a functional option for configuring the NATS logger
@@ -33,6 +37,17 @@ func WithSubject(subject string) NATSOption {
 	}
 }
 
+// WithRetryOnFailedConnect returns a logger even when the server is unreachable and keeps connecting in
+// the background every wait, messages are buffered by the client until the connection is established
+func WithRetryOnFailedConnect(wait time.Duration) NATSOption {
+	return func(n *NATS) {
+		n.retryOnFailedConnect = true
+		if wait > 0 {
+			n.reconnectWait = wait
+		}
+	}
+}
+
 // WithCredentials sets username and password for NATS authentication
 func WithCredentials(username, password string) NATSOption {
 	return func(n *NATS) {
@@ -43,22 +58,17 @@ func WithCredentials(username, password string) NATSOption {
 
 func NewLoggerNATS(url string, minLogLevel Level, options ...NATSOption) (*NATS, error) {
 	logger := &NATS{
-		minLogLevel: minLogLevel,
-		subject:     "logs",                   // Default subject
-		clientID:    "internal-logger-broker", // Default client ID
+		minLogLevel:   minLogLevel,
+		subject:       "logs",                   // Default subject
+		clientID:      "internal-logger-broker", // Default client ID
+		reconnectWait: 2 * time.Second,
 	}
 
 	for _, opt := range options {
 		opt(logger)
 	}
 
-	natsOpts := []nats.Option{
-		nats.Name(logger.clientID),
-		nats.ReconnectWait(2 * time.Second),
-		nats.MaxReconnects(10),
-	}
-
-	nc, err := nats.Connect(url, natsOpts...)
+	nc, err := nats.Connect(url, logger.connectOptions()...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to NATS server: %w", err)
 	}
@@ -69,21 +79,17 @@ func NewLoggerNATS(url string, minLogLevel Level, options ...NATSOption) (*NATS,
 
 func NewLoggerNATSWithAuth(url string, username, password string, minLogLevel Level, options ...NATSOption) (*NATS, error) {
 	logger := &NATS{
-		minLogLevel: minLogLevel,
-		subject:     "logs",                   // Default subject
-		clientID:    "internal-logger-broker", // Default client ID
+		minLogLevel:   minLogLevel,
+		subject:       "logs",                   // Default subject
+		clientID:      "internal-logger-broker", // Default client ID
+		reconnectWait: 2 * time.Second,
 	}
 
 	for _, opt := range options {
 		opt(logger)
 	}
 
-	nc, err := nats.Connect(url,
-		nats.Name(logger.clientID),
-		nats.UserInfo(username, password),
-		nats.ReconnectWait(2*time.Second),
-		nats.MaxReconnects(10),
-	)
+	nc, err := nats.Connect(url, append(logger.connectOptions(), nats.UserInfo(username, password))...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to NATS server: %w", err)
 	}
@@ -92,6 +98,25 @@ func NewLoggerNATSWithAuth(url string, username, password string, minLogLevel Le
 	return logger, nil
 }
 
+func (ln *NATS) connectOptions() []nats.Option {
+	natsOpts := []nats.Option{
+		nats.Name(ln.clientID),
+		nats.ReconnectWait(ln.reconnectWait),
+		nats.MaxReconnects(10),
+	}
+
+	if ln.retryOnFailedConnect {
+		natsOpts = append(natsOpts, nats.RetryOnFailedConnect(true), nats.MaxReconnects(-1))
+	}
+
+	return natsOpts
+}
+
+// IsConnected reports whether the logger is currently connected to the server
+func (ln *NATS) IsConnected() bool {
+	return ln.conn != nil && ln.conn.IsConnected()
+}
+
 func (ln *NATS) LogMessage(level Level, message LogMessage) error {
 	jsonBytes, err := json.Marshal(message)
 	if err != nil {
@@ -102,15 +127,25 @@ func (ln *NATS) LogMessage(level Level, message LogMessage) error {
 }
 
 func (ln *NATS) Log(level Level, message string) error {
+	return ln.LogRaw(level, []byte(message))
+}
+
+// LogRaw publishes an entry encoded by AppendLogMessage, the client copies data before returning
+func (ln *NATS) LogRaw(level Level, data []byte) error {
 	if ln.conn == nil || ln.conn.IsClosed() {
 		return fmt.Errorf("NATS connection is closed or not initialized")
 	}
 
-	err := ln.conn.Publish(ln.subject, []byte(message))
+	err := ln.conn.Publish(ln.subject, data)
 	if err != nil {
 		return err
 	}
 
+	// While (re)connecting the message sits in the client reconnect buffer and is sent once connected
+	if !ln.conn.IsConnected() {
+		return nil
+	}
+
 	err = ln.conn.Flush()
 	if err != nil {
 		return err
@@ -119,6 +154,24 @@ func (ln *NATS) Log(level Level, message string) error {
 	return nil
 }
 
+// Publish sends a raw payload on the given subject using the logger connection
+func (ln *NATS) Publish(subject string, data []byte) error {
+	if ln.conn == nil || ln.conn.IsClosed() {
+		return fmt.Errorf("NATS connection is closed or not initialized")
+	}
+
+	err := ln.conn.Publish(subject, data)
+	if err != nil {
+		return err
+	}
+
+	if !ln.conn.IsConnected() {
+		return nil
+	}
+
+	return ln.conn.Flush()
+}
+
 func (ln *NATS) ShouldLogLevel(level Level) bool {
 	return level >= ln.minLogLevel
 }
@@ -128,3 +181,11 @@ func (ln *NATS) Close() {
 		ln.conn.Close()
 	}
 }
+
+// Name returns the sink name used in metrics
+func (ln *NATS) Name() string {
+	if ln.name != "" {
+		return ln.name
+	}
+	return "nats"
+}
@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// DefaultPanicFlushTimeout bounds the time spent writing the queued entries before a panic is logged
+const DefaultPanicFlushTimeout = 5 * time.Second
+
+// PanicOption configures RecoverAndLog and Go
+type PanicOption func(*panicOptions)
+
+type panicOptions struct {
+	continueOnPanic bool
+	flushTimeout    time.Duration
+}
+
+// ContinueOnPanic recovers the panic once it is logged instead of panicking again
+func ContinueOnPanic() PanicOption {
+	return func(o *panicOptions) {
+		o.continueOnPanic = true
+	}
+}
+
+// WithPanicFlushTimeout replaces DefaultPanicFlushTimeout
+func WithPanicFlushTimeout(timeout time.Duration) PanicOption {
+	return func(o *panicOptions) {
+		o.flushTimeout = timeout
+	}
+}
+
+// RecoverAndLog must be deferred directly, as in defer l.RecoverAndLog(). It recovers a panic and logs its value
+// and the stack of the panicking goroutine at FATAL, after the queued entries and synchronously to every sink, so
+// the reason of a crash is written before the process exits. The panic is raised again unless ContinueOnPanic is
+// given.
+func (l *MultiLogger) RecoverAndLog(options ...PanicOption) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	o := panicOptions{flushTimeout: DefaultPanicFlushTimeout}
+	for _, opt := range options {
+		opt(&o)
+	}
+
+	l.logPanic(recovered, debug.Stack(), o.flushTimeout)
+
+	if !o.continueOnPanic {
+		panic(recovered)
+	}
+}
+
+// Go runs fn in a new goroutine whose panics are logged by RecoverAndLog
+func Go(l *MultiLogger, fn func(), options ...PanicOption) {
+	go func() {
+		defer l.RecoverAndLog(options...)
+		fn()
+	}()
+}
+
+// logPanic writes the panic entry without going through the queue, it is not subject to the minimum level,
+// sampling, deduplication or routing
+func (l *MultiLogger) logPanic(recovered interface{}, stack []byte, flushTimeout time.Duration) {
+	body := fmt.Sprintf("Panic: %v", recovered)
+	timestamp := time.Now()
+
+	if l.stopped.Load() {
+		fallbackLog(FATAL, fmt.Sprintf("%s\n Stack trace : \n%s", body, stack))
+		return
+	}
+
+	if !l.Flush(flushTimeout) {
+		fallbackLog(WARN, "Queued entries were not written before logging a panic")
+	}
+
+	l.metrics.ChTotalMessagesInc()
+	l.metrics.LevelCountInc(FATAL)
+	l.processLog(logEntry{
+		level:     FATAL,
+		message:   fmt.Sprintf("%s - [%s] : %s\n Stack trace : \n%s", timestamp.Format("2006-01-02 15:04:05"), LogLevelToString(FATAL), body, stack),
+		body:      body,
+		fields:    map[string]interface{}{"panic": fmt.Sprint(recovered)},
+		timestamp: timestamp,
+		enqueued:  timestamp,
+		allSinks:  true,
+	})
+}
@@ -0,0 +1,110 @@
+package logger
+
+// IProcessor enriches, transforms or drops a LogMessage before it is handed to the sinks,
+// returning false drops the message
+type IProcessor interface {
+	Process(message *LogMessage) bool
+}
+
+// ProcessorFunc adapts a function to the IProcessor interface
+type ProcessorFunc func(message *LogMessage) bool
+
+func (f ProcessorFunc) Process(message *LogMessage) bool {
+	return f(message)
+}
+
+// WithProcessors appends processors to the chain run on every entry, in the given order
+func WithProcessors(processors ...IProcessor) LoggerOption {
+	return func(l *MultiLogger) {
+		l.processors = append(l.processors, processors...)
+	}
+}
+
+// AddFields sets static fields on every message, fields already present on the message are kept
+func AddFields(fields map[string]interface{}) IProcessor {
+	return ProcessorFunc(func(message *LogMessage) bool {
+		if message.Fields == nil {
+			message.Fields = make(map[string]interface{}, len(fields))
+		}
+		for key, value := range fields {
+			if _, ok := message.Fields[key]; !ok {
+				message.Fields[key] = value
+			}
+		}
+		return true
+	})
+}
+
+// AddTags sets static tags on every message, overriding tags with the same key
+func AddTags(tags map[string]string) IProcessor {
+	return ProcessorFunc(func(message *LogMessage) bool {
+		if message.Tags == nil {
+			message.Tags = make(map[string]string, len(tags))
+		}
+		for key, value := range tags {
+			message.Tags[key] = value
+		}
+		return true
+	})
+}
+
+// RenameKeys renames field and tag keys, from old name to new name
+func RenameKeys(renames map[string]string) IProcessor {
+	return ProcessorFunc(func(message *LogMessage) bool {
+		for from, to := range renames {
+			if value, ok := message.Fields[from]; ok {
+				delete(message.Fields, from)
+				message.Fields[to] = value
+			}
+			if value, ok := message.Tags[from]; ok {
+				delete(message.Tags, from)
+				message.Tags[to] = value
+			}
+		}
+		return true
+	})
+}
+
+// DropIf drops every message matching predicate
+func DropIf(predicate func(message *LogMessage) bool) IProcessor {
+	return ProcessorFunc(func(message *LogMessage) bool {
+		return !predicate(message)
+	})
+}
+
+// Process redacts the message, it allows a Redactor to be placed anywhere in the processor chain
+func (r *Redactor) Process(message *LogMessage) bool {
+	r.RedactMessage(message)
+	return true
+}
+
+// runProcessors runs the processor chain on a copy of the message maps so processors never modify
+// the logger tags or fields shared with other entries
+func (l *MultiLogger) runProcessors(message *LogMessage) bool {
+	if len(l.processors) == 0 {
+		return true
+	}
+
+	tags := make(map[string]string, len(message.Tags))
+	for key, value := range message.Tags {
+		tags[key] = value
+	}
+	message.Tags = tags
+
+	fields := make(map[string]interface{}, len(message.Fields))
+	for key, value := range message.Fields {
+		fields[key] = value
+	}
+	message.Fields = fields
+
+	for _, processor := range l.processors {
+		if !processor.Process(message) {
+			return false
+		}
+	}
+
+	if len(message.Fields) == 0 {
+		message.Fields = nil
+	}
+	return true
+}
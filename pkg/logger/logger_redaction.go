@@ -0,0 +1,186 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/internal/logger"
+)
+
+// DefaultRedactedFields are the field names whose values are always redacted
+var DefaultRedactedFields = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"access_token",
+	"refresh_token",
+	"api_key",
+	"apikey",
+	"authorization",
+	"aws_secret_access_key",
+	"nats_password",
+}
+
+var (
+	emailPattern        = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	cardPattern         = regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`)
+	bearerPattern       = regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9\-._~+/]+=*`)
+	awsAccessKeyPattern = regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)
+)
+
+// Redactor scrubs personal data and secrets from log messages before they reach any sink
+type Redactor struct {
+	replacement    string
+	builtin        bool
+	patterns       []*regexp.Regexp
+	fields         map[string]struct{}
+	jsonFieldRegex *regexp.Regexp
+	textFieldRegex *regexp.Regexp
+}
+
+// RedactorOption is a functional option for configuring the Redactor
+type RedactorOption func(*Redactor)
+
+// WithRedactionPatterns adds regular expressions whose matches are redacted
+func WithRedactionPatterns(patterns ...*regexp.Regexp) RedactorOption {
+	return func(r *Redactor) {
+		r.patterns = append(r.patterns, patterns...)
+	}
+}
+
+// WithRedactedFields adds field names whose values are redacted, matching is case-insensitive
+func WithRedactedFields(names ...string) RedactorOption {
+	return func(r *Redactor) {
+		for _, name := range names {
+			r.fields[strings.ToLower(name)] = struct{}{}
+		}
+	}
+}
+
+// WithRedactionReplacement sets the text that replaces redacted values
+func WithRedactionReplacement(replacement string) RedactorOption {
+	return func(r *Redactor) {
+		r.replacement = replacement
+	}
+}
+
+// WithoutBuiltinDetectors disables the email, credit card, bearer token and AWS key detectors
+func WithoutBuiltinDetectors() RedactorOption {
+	return func(r *Redactor) {
+		r.builtin = false
+	}
+}
+
+// NewRedactor creates a Redactor with the built-in detectors and DefaultRedactedFields
+func NewRedactor(options ...RedactorOption) *Redactor {
+	r := &Redactor{
+		replacement: logger.RedactedValue,
+		builtin:     true,
+		fields:      make(map[string]struct{}),
+	}
+
+	WithRedactedFields(DefaultRedactedFields...)(r)
+	for _, opt := range options {
+		opt(r)
+	}
+
+	if len(r.fields) > 0 {
+		names := make([]string, 0, len(r.fields))
+		for name := range r.fields {
+			names = append(names, regexp.QuoteMeta(name))
+		}
+		alternatives := strings.Join(names, "|")
+
+		r.jsonFieldRegex = regexp.MustCompile(`(?i)("(?:` + alternatives + `)"\s*:\s*)("(?:[^"\\]|\\.)*"|[^,}\]\s]+)`)
+		r.textFieldRegex = regexp.MustCompile(`(?i)\b((?:` + alternatives + `)\s*[=:]\s*)((?:bearer\s+)?[^\s&,;"]+)`)
+	}
+
+	return r
+}
+
+// WithRedactor scrubs every entry with the given Redactor before it is handed to the sinks
+func WithRedactor(r *Redactor) LoggerOption {
+	return func(l *MultiLogger) {
+		l.redactor = r
+	}
+}
+
+// Redact returns s with every detected secret replaced
+func (r *Redactor) Redact(s string) string {
+	if r.jsonFieldRegex != nil {
+		s = r.jsonFieldRegex.ReplaceAllString(s, `${1}"`+r.replacement+`"`)
+		s = r.textFieldRegex.ReplaceAllString(s, `${1}`+r.replacement)
+	}
+
+	if r.builtin {
+		s = bearerPattern.ReplaceAllString(s, `${1}`+r.replacement)
+		s = awsAccessKeyPattern.ReplaceAllString(s, r.replacement)
+		s = emailPattern.ReplaceAllString(s, r.replacement)
+		s = cardPattern.ReplaceAllStringFunc(s, func(match string) string {
+			if luhnValid(match) {
+				return r.replacement
+			}
+			return match
+		})
+	}
+
+	for _, pattern := range r.patterns {
+		s = pattern.ReplaceAllString(s, r.replacement)
+	}
+
+	return s
+}
+
+// IsRedactedField reports whether values of the named field are always redacted
+func (r *Redactor) IsRedactedField(name string) bool {
+	_, ok := r.fields[strings.ToLower(name)]
+	return ok
+}
+
+// RedactMessage scrubs the message text and the fields of a LogMessage in place
+func (r *Redactor) RedactMessage(message *LogMessage) {
+	message.Message = r.Redact(message.Message)
+
+	if len(message.Fields) == 0 {
+		return
+	}
+
+	fields := make(map[string]interface{}, len(message.Fields))
+	for key, value := range message.Fields {
+		if r.IsRedactedField(key) {
+			fields[key] = r.replacement
+		} else if s, ok := value.(string); ok {
+			fields[key] = r.Redact(s)
+		} else {
+			fields[key] = value
+		}
+	}
+	message.Fields = fields
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum used by card numbers
+func luhnValid(s string) bool {
+	var sum, count int
+	double := false
+
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		count += 1
+		double = !double
+	}
+
+	return count >= 13 && count <= 19 && sum%10 == 0
+}
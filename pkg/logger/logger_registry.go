@@ -0,0 +1,315 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// SinkDefinition describes a sink in the configuration, every key besides type, name and min_level
+// is passed to the sink factory
+type SinkDefinition struct {
+	Type     string
+	Name     string
+	MinLevel string
+
+	settings map[string]interface{}
+}
+
+// SinkFactory builds a sink from its definition
+type SinkFactory func(definition SinkDefinition) (ILogger, error)
+
+// SinkValidator checks a sink definition without building the sink, for example by probing a server
+type SinkValidator func(definition SinkDefinition) error
+
+// ICloser is implemented by sinks holding resources that must be released when they are replaced
+type ICloser interface {
+	Close()
+}
+
+var (
+	sinkRegistry      = make(map[string]SinkFactory)
+	sinkValidators    = make(map[string]SinkValidator)
+	sinkRegistryMutex sync.RWMutex
+)
+
+func init() {
+	RegisterSink("console", newConsoleSink)
+	RegisterSink("nats", newNATSSink)
+	RegisterSinkValidator("nats", validateNATSSink)
+}
+
+// RegisterSink makes a sink type available to the configuration, registering an existing name replaces it
+func RegisterSink(name string, factory SinkFactory) {
+	sinkRegistryMutex.Lock()
+	defer sinkRegistryMutex.Unlock()
+	sinkRegistry[name] = factory
+}
+
+// RegisterSinkValidator adds a validator used by Configuration.Validate for sinks of the given type
+func RegisterSinkValidator(name string, validator SinkValidator) {
+	sinkRegistryMutex.Lock()
+	defer sinkRegistryMutex.Unlock()
+	sinkValidators[name] = validator
+}
+
+// RegisteredSinks returns the names of all registered sink types
+func RegisteredSinks() []string {
+	sinkRegistryMutex.RLock()
+	defer sinkRegistryMutex.RUnlock()
+
+	names := make([]string, 0, len(sinkRegistry))
+	for name := range sinkRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewSink builds a sink from its definition with the registered factory of its type
+func NewSink(definition SinkDefinition) (ILogger, error) {
+	sinkRegistryMutex.RLock()
+	factory, ok := sinkRegistry[definition.Type]
+	sinkRegistryMutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown sink type %q", definition.Type)
+	}
+
+	sink, err := factory(definition)
+	if err != nil {
+		return nil, fmt.Errorf("sink %s: %w", definition.SinkName(), err)
+	}
+
+	if name := definition.SinkName(); sinkName(sink, 0) != name {
+		sink = &namedSink{ILogger: sink, name: name}
+	}
+	return sink, nil
+}
+
+// ValidateSink checks a sink definition with the validator registered for its type, if any
+func ValidateSink(definition SinkDefinition) error {
+	return validateSink(definition, true)
+}
+
+func validateSink(definition SinkDefinition, checkLevel bool) error {
+	sinkRegistryMutex.RLock()
+	_, known := sinkRegistry[definition.Type]
+	validator := sinkValidators[definition.Type]
+	sinkRegistryMutex.RUnlock()
+
+	if !known {
+		return fmt.Errorf("sink %s: unknown sink type %q", definition.SinkName(), definition.Type)
+	}
+	if checkLevel {
+		if _, err := definition.Level(); err != nil {
+			return fmt.Errorf("sink %s: %w", definition.SinkName(), err)
+		}
+	}
+	if validator == nil {
+		return nil
+	}
+	if err := validator(definition); err != nil {
+		return fmt.Errorf("sink %s: %w", definition.SinkName(), err)
+	}
+	return nil
+}
+
+// NewSinkDefinition creates a definition with the given settings
+func NewSinkDefinition(sinkType, name, minLevel string, settings map[string]interface{}) SinkDefinition {
+	return SinkDefinition{Type: sinkType, Name: name, MinLevel: minLevel, settings: settings}
+}
+
+// SinkName returns the name of the sink, its type when no name is set
+func (d SinkDefinition) SinkName() string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return d.Type
+}
+
+// Level returns the minimum level of the sink, DEBUG when none is set
+func (d SinkDefinition) Level() (Level, error) {
+	if d.MinLevel == "" {
+		return DEBUG, nil
+	}
+	return ParseLevel(d.MinLevel)
+}
+
+// Setting returns a single setting of the definition
+func (d SinkDefinition) Setting(key string) (interface{}, bool) {
+	value, ok := d.settings[key]
+	return value, ok
+}
+
+// Decode unmarshals the settings of the definition into target using its json tags
+func (d SinkDefinition) Decode(target interface{}) error {
+	jsonBytes, err := json.Marshal(d.settings)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonBytes, target)
+}
+
+func (d *SinkDefinition) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	d.settings = make(map[string]interface{}, len(raw))
+	for key, value := range raw {
+		var ok bool
+		switch key {
+		case "type":
+			d.Type, ok = value.(string)
+		case "name":
+			d.Name, ok = value.(string)
+		case "min_level":
+			d.MinLevel, ok = value.(string)
+		default:
+			d.settings[key] = value
+			continue
+		}
+		if !ok {
+			return fmt.Errorf("sink definition: %s must be a string", key)
+		}
+	}
+	return nil
+}
+
+func (d SinkDefinition) MarshalJSON() ([]byte, error) {
+	raw := make(map[string]interface{}, len(d.settings)+3)
+	for key, value := range d.settings {
+		raw[key] = value
+	}
+	raw["type"] = d.Type
+	if d.Name != "" {
+		raw["name"] = d.Name
+	}
+	if d.MinLevel != "" {
+		raw["min_level"] = d.MinLevel
+	}
+	return json.Marshal(raw)
+}
+
+// namedSink gives a sink the name it was configured with
+type namedSink struct {
+	ILogger
+	name string
+}
+
+func (n *namedSink) Name() string {
+	return n.name
+}
+
+func (n *namedSink) Close() {
+	if closer, ok := n.ILogger.(ICloser); ok {
+		closer.Close()
+	}
+}
+
+// Unwrap returns the configured sink
+func (n *namedSink) Unwrap() ILogger {
+	return n.ILogger
+}
+
+// unwrapSink returns the sink built by the factory, without the name wrapper
+func unwrapSink(sink ILogger) ILogger {
+	if named, ok := sink.(*namedSink); ok {
+		return named.ILogger
+	}
+	return sink
+}
+
+// NATSSinkSettings are the settings of a "nats" sink definition
+type NATSSinkSettings struct {
+	URL      string `json:"url"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Subject  string `json:"subject"`
+	ClientID string `json:"client_id"`
+
+	// RetryOnFailedConnect builds the sink even if the server is unreachable and keeps connecting in the background
+	RetryOnFailedConnect bool `json:"retry_on_failed_connect"`
+	RetryIntervalSeconds int  `json:"retry_interval_seconds"`
+}
+
+func newConsoleSink(definition SinkDefinition) (ILogger, error) {
+	level, err := definition.Level()
+	if err != nil {
+		return nil, err
+	}
+
+	console := NewLoggerConsole(level)
+	console.name = definition.SinkName()
+	return console, nil
+}
+
+func newNATSSink(definition SinkDefinition) (ILogger, error) {
+	level, err := definition.Level()
+	if err != nil {
+		return nil, err
+	}
+
+	var settings NATSSinkSettings
+	if err := definition.Decode(&settings); err != nil {
+		return nil, err
+	}
+
+	var options []NATSOption
+	if settings.Subject != "" {
+		options = append(options, WithSubject(settings.Subject))
+	}
+	if settings.ClientID != "" {
+		options = append(options, WithClientID(settings.ClientID))
+	}
+	if settings.RetryOnFailedConnect {
+		options = append(options, WithRetryOnFailedConnect(time.Duration(settings.RetryIntervalSeconds)*time.Second))
+	}
+
+	var natsLogger *NATS
+	if settings.Username != "" && settings.Password != "" {
+		natsLogger, err = NewLoggerNATSWithAuth(settings.URL, settings.Username, settings.Password, level, options...)
+	} else {
+		natsLogger, err = NewLoggerNATS(settings.URL, level, options...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	natsLogger.name = definition.SinkName()
+	return natsLogger, nil
+}
+
+// validateNATSSink requires a url and checks that the server accepts a connection, unless the sink
+// is allowed to connect later
+func validateNATSSink(definition SinkDefinition) error {
+	var settings NATSSinkSettings
+	if err := definition.Decode(&settings); err != nil {
+		return err
+	}
+
+	if settings.URL == "" {
+		return fmt.Errorf("missing url")
+	}
+	if settings.RetryOnFailedConnect {
+		return nil
+	}
+
+	natsOpts := []nats.Option{nats.Timeout(2 * time.Second), nats.NoReconnect()}
+	if settings.Username != "" && settings.Password != "" {
+		natsOpts = append(natsOpts, nats.UserInfo(settings.Username, settings.Password))
+	}
+
+	nc, err := nats.Connect(settings.URL, natsOpts...)
+	if err != nil {
+		return fmt.Errorf("server %s unreachable: %w", settings.URL, err)
+	}
+	nc.Close()
+	return nil
+}
@@ -0,0 +1,157 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Reconfigure applies a configuration to a running logger. The minimum level, tags, sampling, redaction and
+// routing are rebuilt from the options the logger was created with and the configuration on top of them, so
+// settings given in code are kept. Sinks whose definition did not change are kept with their metrics and the
+// others are built again, entries already queued are written to the new sinks. A configuration without sinks
+// keeps the current ones. Nothing is applied when the configuration is invalid or one of its sinks cannot be built.
+// Heartbeat, dedup and span event settings only take effect when the logger is created. Concurrent calls, such
+// as a file and a NATS KV watcher, are applied one after the other.
+func (l *MultiLogger) Reconfigure(c *Configuration) error {
+	l.reconfigureMutex.Lock()
+	defer l.reconfigureMutex.Unlock()
+
+	if l.stopped.Load() {
+		return fmt.Errorf("logger is stopped")
+	}
+	if errs := c.validateStatic(); len(errs) > 0 {
+		return fmt.Errorf("invalid logger configuration: %w", errors.Join(errs...))
+	}
+
+	l.mutex.RLock()
+	previous := make(map[string]SinkDefinition)
+	if l.configuration != nil {
+		for _, definition := range l.configuration.SinkDefinitions() {
+			previous[definition.SinkName()] = definition
+		}
+	}
+	current := make(map[string]*sink, len(l.sinks))
+	for _, s := range l.sinks {
+		current[s.name] = s
+	}
+	hostname := l.tags["hostname"]
+	l.mutex.RUnlock()
+
+	var sinks []*sink
+	var built []ILogger
+	var publisher IPublisher
+	var errs []error
+
+	definitions := c.SinkDefinitions()
+	if len(definitions) == 0 {
+		l.mutex.RLock()
+		sinks = append(sinks, l.sinks...)
+		l.mutex.RUnlock()
+		current = nil
+
+		// Keep the sink definitions so that the next configuration is compared with them
+		if len(previous) > 0 {
+			inherited := *c
+			for _, s := range sinks {
+				if definition, ok := previous[s.name]; ok {
+					inherited.Sinks = append(inherited.Sinks, definition)
+				}
+			}
+			c = &inherited
+		}
+	}
+
+	for _, s := range sinks {
+		if p, ok := unwrapSink(s.logger).(IPublisher); ok && publisher == nil {
+			publisher = p
+		}
+	}
+
+	for _, definition := range definitions {
+		name := definition.SinkName()
+		s, ok := current[name]
+		if old, known := previous[name]; ok && known && sameSinkDefinition(old, definition) {
+			delete(current, name)
+		} else {
+			sinkLogger, err := NewSink(definition)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			built = append(built, sinkLogger)
+			s = &sink{
+				name:    sinkName(sinkLogger, len(sinks)),
+				logger:  sinkLogger,
+				latency: NewHistogram(l.latencyBuckets...),
+			}
+		}
+		sinks = append(sinks, s)
+
+		if p, ok := unwrapSink(s.logger).(IPublisher); ok && publisher == nil {
+			publisher = p
+		}
+	}
+
+	if len(errs) > 0 {
+		closeSinks(built)
+		return fmt.Errorf("invalid logger configuration: %w", errors.Join(errs...))
+	}
+
+	next := &MultiLogger{tags: map[string]string{"hostname": hostname}}
+	for _, opt := range l.options {
+		opt(next)
+	}
+	for _, opt := range c.loggerOptions(publisher) {
+		opt(next)
+	}
+
+	l.mutex.Lock()
+	previousSampling := l.sampling
+	l.sinks = sinks
+	l.tags = next.tags
+	l.sampling = next.sampling
+	l.redactor = next.redactor
+	l.router = next.router
+	l.configuration = c
+	if l.heartbeat != nil && publisher != nil {
+		l.heartbeat.publisher = publisher
+	}
+	l.mutex.Unlock()
+
+	l.minLevel.Store(next.minLevel.Load())
+
+	// Removed sinks are closed once no entry is being written to them
+	if len(current) > 0 {
+		l.writeMutex.Lock()
+		l.writeMutex.Unlock()
+	}
+	for _, s := range current {
+		if closer, ok := s.logger.(ICloser); ok {
+			closer.Close()
+		}
+	}
+
+	if previousSampling != nil {
+		close(previousSampling.done)
+	}
+	if next.sampling != nil {
+		go l.startSamplingSummary(next.sampling)
+	}
+
+	return nil
+}
+
+// Configuration returns the configuration last applied to the logger, nil if it was not built from one
+func (l *MultiLogger) Configuration() *Configuration {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.configuration
+}
+
+func sameSinkDefinition(a, b SinkDefinition) bool {
+	aBytes, aErr := json.Marshal(a)
+	bBytes, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aBytes, bBytes)
+}
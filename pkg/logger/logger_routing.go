@@ -0,0 +1,184 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Route directs the entries matching all of its conditions to the named sinks, empty conditions match
+// every entry
+type Route struct {
+	Name      string            `json:"name"`
+	MinLevel  string            `json:"min_level"`
+	MaxLevel  string            `json:"max_level"`
+	Tags      map[string]string `json:"tags"`      // matched against tags and string fields
+	Component string            `json:"component"` // matched against the "component" field or tag
+	Message   string            `json:"message"`   // regular expression matched against the message
+	Sinks     []string          `json:"sinks"`
+	Continue  bool              `json:"continue"` // keep evaluating the next routes after a match
+}
+
+// Routing holds the ordered routes of a MultiLogger, entries matching no route are sent to
+// DefaultSinks, or to every sink when DefaultSinks is empty
+type Routing struct {
+	Routes       []Route  `json:"routes"`
+	DefaultSinks []string `json:"default_sinks"`
+}
+
+type compiledRoute struct {
+	Route
+	minLevel Level
+	maxLevel Level
+	message  *regexp.Regexp
+}
+
+type router struct {
+	routes       []compiledRoute
+	defaultSinks map[string]bool
+}
+
+// WithRouting routes entries to specific sinks, invalid routes are reported and skipped
+func WithRouting(routing Routing) LoggerOption {
+	return func(l *MultiLogger) {
+		r, err := routing.compile()
+		if err != nil {
+			fallbackLog(ERROR, fmt.Sprintln("Error configuring routing: ", err))
+		}
+		l.router = r
+	}
+}
+
+// Validate reports every invalid route
+func (r Routing) Validate() error {
+	_, err := r.compile()
+	return err
+}
+
+// validateSinkNames reports the sinks of the routes and DefaultSinks that are not defined
+func (r Routing) validateSinkNames(defined map[string]bool) []error {
+	var errs []error
+
+	for i, route := range r.Routes {
+		name := route.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		for _, sink := range route.Sinks {
+			if !defined[sink] {
+				errs = append(errs, fmt.Errorf("routing: route %s: unknown sink %q", name, sink))
+			}
+		}
+	}
+
+	for _, sink := range r.DefaultSinks {
+		if !defined[sink] {
+			errs = append(errs, fmt.Errorf("routing: default_sinks: unknown sink %q", sink))
+		}
+	}
+
+	return errs
+}
+
+func (r Routing) compile() (*router, error) {
+	var errs []error
+	compiled := &router{}
+
+	for i, route := range r.Routes {
+		c := compiledRoute{Route: route, minLevel: DEBUG, maxLevel: UNKNOWN}
+		name := route.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+
+		var err error
+		if route.MinLevel != "" {
+			if c.minLevel, err = ParseLevel(route.MinLevel); err != nil {
+				errs = append(errs, fmt.Errorf("route %s: %w", name, err))
+				continue
+			}
+		}
+		if route.MaxLevel != "" {
+			if c.maxLevel, err = ParseLevel(route.MaxLevel); err != nil {
+				errs = append(errs, fmt.Errorf("route %s: %w", name, err))
+				continue
+			}
+		}
+		if route.Message != "" {
+			if c.message, err = regexp.Compile(route.Message); err != nil {
+				errs = append(errs, fmt.Errorf("route %s: invalid message pattern: %w", name, err))
+				continue
+			}
+		}
+		if len(route.Sinks) == 0 {
+			errs = append(errs, fmt.Errorf("route %s: no sinks", name))
+			continue
+		}
+
+		compiled.routes = append(compiled.routes, c)
+	}
+
+	if len(r.DefaultSinks) > 0 {
+		compiled.defaultSinks = make(map[string]bool, len(r.DefaultSinks))
+		for _, name := range r.DefaultSinks {
+			compiled.defaultSinks[name] = true
+		}
+	}
+
+	return compiled, errors.Join(errs...)
+}
+
+// targets returns the names of the sinks an entry is sent to, nil means every sink
+func (r *router) targets(level Level, message *LogMessage) map[string]bool {
+	var targets map[string]bool
+
+	for _, route := range r.routes {
+		if !route.matches(level, message) {
+			continue
+		}
+		if targets == nil {
+			targets = make(map[string]bool, len(route.Sinks))
+		}
+		for _, name := range route.Sinks {
+			targets[name] = true
+		}
+		if !route.Continue {
+			break
+		}
+	}
+
+	if targets == nil {
+		return r.defaultSinks
+	}
+	return targets
+}
+
+func (r *compiledRoute) matches(level Level, message *LogMessage) bool {
+	if level < r.minLevel || level > r.maxLevel {
+		return false
+	}
+
+	for key, value := range r.Tags {
+		if lookupString(message, key) != value {
+			return false
+		}
+	}
+
+	if r.Component != "" && lookupString(message, "component") != r.Component {
+		return false
+	}
+
+	if r.message != nil && !r.message.MatchString(message.Message) {
+		return false
+	}
+
+	return true
+}
+
+// lookupString returns a string field of the message, or the tag with the same key
+func lookupString(message *LogMessage, key string) string {
+	if value, ok := message.Fields[key].(string); ok {
+		return value
+	}
+	return message.Tags[key]
+}
@@ -0,0 +1,263 @@
+package logger
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SamplerKey selects how the sampler groups entries
+type SamplerKey int
+
+const (
+	// SampleByTemplate groups entries by level and message template (format string or first argument)
+	SampleByTemplate SamplerKey = iota
+	// SampleByCaller groups entries by level and the file and line that logged them
+	SampleByCaller
+)
+
+// SamplerOptions configures per key sampling: the first First entries of each key are logged in every
+// Interval, after that only every Thereafter-th entry is logged (none if Thereafter is zero)
+type SamplerOptions struct {
+	Interval   time.Duration
+	First      int
+	Thereafter int
+	Key        SamplerKey
+}
+
+// DefaultSamplingSummaryInterval is the interval between summaries of suppressed messages
+const DefaultSamplingSummaryInterval = time.Minute
+
+var packagePrefix = reflect.TypeOf(MultiLogger{}).PkgPath() + "."
+
+type sampleCounter struct {
+	windowStart time.Time
+	count       int64
+}
+
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) allow(now time.Time) bool {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens -= 1
+	return true
+}
+
+// sampling holds the sampler and the per level rate limiters sitting in front of MultiLogger.log
+type sampling struct {
+	sampler         *SamplerOptions
+	limits          [UNKNOWN + 1]*tokenBucket
+	summaryInterval time.Duration
+
+	counters   map[string]*sampleCounter
+	suppressed map[string]int64
+	mutex      sync.Mutex
+
+	// done stops the summary of a sampling replaced by Reconfigure
+	done chan struct{}
+}
+
+func (l *MultiLogger) getSampling() *sampling {
+	if l.sampling == nil {
+		l.sampling = &sampling{
+			summaryInterval: DefaultSamplingSummaryInterval,
+			counters:        make(map[string]*sampleCounter),
+			suppressed:      make(map[string]int64),
+			done:            make(chan struct{}),
+		}
+	}
+	return l.sampling
+}
+
+// WithSampler enables per key sampling of log entries
+func WithSampler(options SamplerOptions) LoggerOption {
+	return func(l *MultiLogger) {
+		if options.Interval <= 0 {
+			options.Interval = time.Second
+		}
+		l.getSampling().sampler = &options
+	}
+}
+
+// WithRateLimit limits entries of the given level to perSecond with bursts of up to burst entries
+func WithRateLimit(level Level, perSecond float64, burst int) LoggerOption {
+	return func(l *MultiLogger) {
+		if !isValidLogLevel(level) {
+			return
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		l.getSampling().limits[level] = &tokenBucket{
+			rate:   perSecond,
+			burst:  float64(burst),
+			tokens: float64(burst),
+			last:   time.Now(),
+		}
+	}
+}
+
+// WithSamplingSummaryInterval sets how often the number of suppressed messages is logged
+func WithSamplingSummaryInterval(interval time.Duration) LoggerOption {
+	return func(l *MultiLogger) {
+		if interval > 0 {
+			l.getSampling().summaryInterval = interval
+		}
+	}
+}
+
+// allow reports whether an entry should be logged, it runs before any formatting so that
+// suppressed entries are cheap. Entries without a template, such as those of LogContext, are keyed by caller.
+func (l *MultiLogger) allow(level Level, template string) bool {
+	l.mutex.RLock()
+	s := l.sampling
+	l.mutex.RUnlock()
+	if s == nil {
+		return true
+	}
+
+	var key string
+	if s.sampler != nil {
+		if s.sampler.Key == SampleByCaller || template == "" {
+			key = LogLevelToString(level) + " " + callerLocation()
+		} else {
+			key = LogLevelToString(level) + " " + template
+		}
+	}
+
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if bucket := s.limits[level]; bucket != nil && !bucket.allow(now) {
+		s.suppressed["rate limit "+LogLevelToString(level)] += 1
+		l.metrics.SuppressedMessagesInc()
+		return false
+	}
+
+	if s.sampler == nil {
+		return true
+	}
+
+	counter, ok := s.counters[key]
+	if !ok || now.Sub(counter.windowStart) >= s.sampler.Interval {
+		counter = &sampleCounter{windowStart: now}
+		s.counters[key] = counter
+	}
+	counter.count += 1
+
+	if counter.count <= int64(s.sampler.First) {
+		return true
+	}
+	if s.sampler.Thereafter > 0 && (counter.count-int64(s.sampler.First))%int64(s.sampler.Thereafter) == 0 {
+		return true
+	}
+
+	s.suppressed[key] += 1
+	l.metrics.SuppressedMessagesInc()
+	return false
+}
+
+func (l *MultiLogger) startSamplingSummary(s *sampling) {
+	ticker := time.NewTicker(s.summaryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.logSamplingSummary(s)
+		case <-s.done:
+			l.logSamplingSummary(s)
+			return
+		case <-l.quitLogCh:
+			return
+		}
+	}
+}
+
+// logSamplingSummary logs the suppressed message counts since the last summary and drops expired counters
+func (l *MultiLogger) logSamplingSummary(s *sampling) {
+	now := time.Now()
+
+	s.mutex.Lock()
+	suppressed := s.suppressed
+	s.suppressed = make(map[string]int64)
+	if s.sampler != nil {
+		for key, counter := range s.counters {
+			if now.Sub(counter.windowStart) >= s.sampler.Interval {
+				delete(s.counters, key)
+			}
+		}
+	}
+	s.mutex.Unlock()
+
+	if len(suppressed) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(suppressed))
+	var total int64
+	for key, count := range suppressed {
+		keys = append(keys, key)
+		total += count
+	}
+	sort.Slice(keys, func(i, j int) bool { return suppressed[keys[i]] > suppressed[keys[j]] })
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%s - [%s] : Sampling suppressed %d messages in the last %s:", now.Format("2006-01-02 15:04:05"), LogLevelToString(WARN), total, s.summaryInterval))
+	for i, key := range keys {
+		if i == 10 {
+			builder.WriteString(fmt.Sprintf(" ... and %d more", len(keys)-i))
+			break
+		}
+		builder.WriteString(fmt.Sprintf(" [%s] x%d;", key, suppressed[key]))
+	}
+
+	l.log(logEntry{level: WARN, message: builder.String()})
+}
+
+// callerLocation returns file:line of the first caller outside of this package
+func callerLocation() string {
+	var pcs [16]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePrefix) {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// messageTemplate returns the sampling template of a Log call
+func messageTemplate(args []interface{}) string {
+	if len(args) == 0 {
+		return ""
+	}
+	if s, ok := args[0].(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%T", args[0])
+}
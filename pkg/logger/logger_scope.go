@@ -0,0 +1,152 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultScopeCapacity is the number of entries held by a scope when no capacity is given
+const DefaultScopeCapacity = 256
+
+// scopeFlushTimeout bounds the time a flush waits for room in a full queue, the entries still waiting are then
+// written to the fallback logger
+const scopeFlushTimeout = 100 * time.Millisecond
+
+type scopeContextKey struct{}
+
+// Scope holds the DEBUG and INFO entries logged with its context until the unit of work ends. They are
+// discarded by End, unless an ERROR or FATAL entry is logged with the context or Flush is called: the held
+// entries are then logged in order with their original timestamps, followed by every later entry of the scope.
+// Only the context-aware calls, LogContext and LogContextf, are held, and the logger minimum level still applies.
+type Scope struct {
+	capacity int
+	entries  []scopedEntry
+	head     int
+	dropped  int64
+	failed   bool
+	ended    bool
+	mutex    sync.Mutex
+}
+
+type scopedEntry struct {
+	logger *MultiLogger
+	entry  logEntry
+}
+
+// NewScopeContext starts a scope holding up to capacity entries, the oldest are dropped when it is full
+func NewScopeContext(ctx context.Context, capacity int) (context.Context, *Scope) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if capacity <= 0 {
+		capacity = DefaultScopeCapacity
+	}
+
+	scope := &Scope{capacity: capacity}
+	return context.WithValue(ctx, scopeContextKey{}, scope), scope
+}
+
+func scopeFromContext(ctx context.Context) *Scope {
+	scope, _ := ctx.Value(scopeContextKey{}).(*Scope)
+	return scope
+}
+
+// Flush logs the held entries and lets every later entry of the scope through
+func (s *Scope) Flush() {
+	s.mutex.Lock()
+	s.failed = true
+	held, dropped := s.take()
+	s.mutex.Unlock()
+
+	flushScope(held, dropped)
+}
+
+// End discards the held entries unless the scope failed, entries logged afterwards are not held
+func (s *Scope) End() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ended = true
+	s.entries = nil
+	s.head = 0
+}
+
+// Failed reports whether an error was logged in the scope or it was flushed
+func (s *Scope) Failed() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.failed
+}
+
+// hold keeps an entry of the scope and reports whether it was held, an ERROR or FATAL entry flushes the scope
+// before it is logged
+func (s *Scope) hold(l *MultiLogger, entry logEntry) bool {
+	s.mutex.Lock()
+
+	if s.failed || s.ended || entry.level == WARN {
+		s.mutex.Unlock()
+		return false
+	}
+
+	if entry.level >= ERROR {
+		s.failed = true
+		held, dropped := s.take()
+		s.mutex.Unlock()
+
+		flushScope(held, dropped)
+		return false
+	}
+
+	if len(s.entries) < s.capacity {
+		s.entries = append(s.entries, scopedEntry{logger: l, entry: entry})
+	} else {
+		s.entries[s.head] = scopedEntry{logger: l, entry: entry}
+		s.head = (s.head + 1) % s.capacity
+		s.dropped++
+	}
+
+	s.mutex.Unlock()
+	return true
+}
+
+// take returns the held entries in order and empties the buffer
+func (s *Scope) take() ([]scopedEntry, int64) {
+	held := append(append([]scopedEntry(nil), s.entries[s.head:]...), s.entries[:s.head]...)
+	dropped := s.dropped
+
+	s.entries = nil
+	s.head = 0
+	s.dropped = 0
+	return held, dropped
+}
+
+// flushScope logs the held entries as priority entries, a scope holds more entries than the queue lets through
+// when it is near full and they are the context of the error that flushed it. The caller waits at most
+// scopeFlushTimeout for the whole flush.
+func flushScope(held []scopedEntry, dropped int64) {
+	if len(held) == 0 {
+		return
+	}
+
+	deadline := time.Now().Add(scopeFlushTimeout)
+	if dropped > 0 {
+		body := fmt.Sprintf("Scope buffer full, %d earlier entries were dropped", dropped)
+		held[0].logger.log(logEntry{level: WARN, message: formatMessage(WARN, body), body: body, deadline: deadline})
+	}
+	for _, e := range held {
+		e.entry.deadline = deadline
+		e.logger.log(e.entry)
+	}
+}
+
+// logScoped logs an entry of a context-aware call, unless the scope of the context holds it
+func (l *MultiLogger) logScoped(ctx context.Context, entry logEntry) {
+	entry.timestamp = time.Now()
+
+	if scope := scopeFromContext(ctx); scope != nil && scope.hold(l, entry) {
+		return
+	}
+	l.log(entry)
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceContext is the OpenTelemetry span of an entry logged with a context
+type traceContext struct {
+	traceID    string
+	spanID     string
+	traceFlags string
+}
+
+// WithSpanEvents also records the entries logged with a context as events of the span in the context,
+// with the log.severity and log.message attributes
+func WithSpanEvents() LoggerOption {
+	return func(l *MultiLogger) {
+		l.spanEvents = true
+	}
+}
+
+// traceFromContext returns the trace and span ids of the span in ctx, empty when there is none
+func traceFromContext(ctx context.Context) traceContext {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return traceContext{}
+	}
+
+	return traceContext{
+		traceID:    spanContext.TraceID().String(),
+		spanID:     spanContext.SpanID().String(),
+		traceFlags: spanContext.TraceFlags().String(),
+	}
+}
+
+// recordSpanEvent adds the entry to the span in ctx if it is recording, it runs on the calling goroutine
+// because the span may end before the entry is written. The body is redacted as it would be by the sinks.
+func (l *MultiLogger) recordSpanEvent(ctx context.Context, level Level, body string) {
+	if !l.spanEvents {
+		return
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	span.AddEvent("log", trace.WithAttributes(
+		attribute.String("log.severity", LogLevelToString(level)),
+		attribute.String("log.message", l.redact(body)),
+	))
+}
@@ -0,0 +1,179 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var sinkDefinitionType = reflect.TypeOf(SinkDefinition{})
+
+// Validate checks the whole configuration and returns every problem found joined in a single error:
+// unknown fields, invalid levels and patterns, sinks without a url and servers that cannot be reached
+func (c *Configuration) Validate() error {
+	errs := c.validateStatic()
+
+	// Sink levels are already checked by validateStatic
+	for _, definition := range c.SinkDefinitions() {
+		if err := validateSink(definition, false); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateStatic checks everything that does not need a connection
+func (c *Configuration) validateStatic() []error {
+	var errs []error
+
+	for _, field := range c.unknownFields {
+		errs = append(errs, fmt.Errorf("unknown field %s", field))
+	}
+
+	if c.Version != 0 && c.Version != 1 && c.Version != 2 {
+		errs = append(errs, fmt.Errorf("unsupported version %d", c.Version))
+	}
+
+	if len(c.Sinks) > 0 && (c.UseConsole || c.UseNATS) {
+		errs = append(errs, fmt.Errorf("sinks and use_console/use_nats cannot be combined"))
+	}
+
+	for i, definition := range c.Sinks {
+		if definition.Type == "" {
+			errs = append(errs, fmt.Errorf("sinks[%d]: missing type", i))
+		}
+	}
+
+	// Sink names identify sinks in routes and across reloads, sinks of the same type need a name each
+	names := make(map[string]bool)
+	for _, definition := range c.SinkDefinitions() {
+		if _, err := definition.Level(); err != nil {
+			errs = append(errs, fmt.Errorf("sink %s: %w", definition.SinkName(), err))
+		}
+		if names[definition.SinkName()] {
+			errs = append(errs, fmt.Errorf("sink %s: duplicate sink name, set a unique name", definition.SinkName()))
+		}
+		names[definition.SinkName()] = true
+	}
+
+	if c.MinLevel != "" {
+		if _, err := ParseLevel(c.MinLevel); err != nil {
+			errs = append(errs, fmt.Errorf("min_level: %w", err))
+		}
+	}
+
+	if c.HeartbeatIntervalSeconds < 0 {
+		errs = append(errs, fmt.Errorf("heartbeat_interval_seconds must not be negative"))
+	}
+	if c.DedupWindowSeconds < 0 {
+		errs = append(errs, fmt.Errorf("dedup_window_seconds must not be negative"))
+	}
+
+	if c.Sampling != nil {
+		for name := range c.Sampling.RateLimits {
+			if _, err := ParseLevel(name); err != nil {
+				errs = append(errs, fmt.Errorf("sampling.rate_limits: %w", err))
+			}
+		}
+		if c.Sampling.First < 0 || c.Sampling.Thereafter < 0 {
+			errs = append(errs, fmt.Errorf("sampling: first and thereafter must not be negative"))
+		}
+	}
+
+	if c.Redaction != nil {
+		for _, expr := range c.Redaction.Patterns {
+			if _, err := regexp.Compile(expr); err != nil {
+				errs = append(errs, fmt.Errorf("redaction.patterns: %w", err))
+			}
+		}
+	}
+
+	if c.Routing != nil {
+		if err := c.Routing.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("routing: %w", err))
+		}
+
+		// A configuration without sinks keeps the sinks of the running logger, their names are not known here
+		if len(names) > 0 {
+			errs = append(errs, c.Routing.validateSinkNames(names)...)
+		}
+	}
+
+	return errs
+}
+
+// unknownJSONFields returns the keys of data that do not map to a field of t, nested objects included
+func unknownJSONFields(data []byte, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t == sinkDefinitionType {
+			return nil
+		}
+
+		var raw map[string]json.RawMessage
+		if json.Unmarshal(data, &raw) != nil {
+			return nil
+		}
+
+		known := make(map[string]reflect.Type)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			known[strings.ToLower(name)] = f.Type
+		}
+
+		var unknown []string
+		for key, value := range raw {
+			fieldType, ok := known[strings.ToLower(key)]
+			if !ok {
+				unknown = append(unknown, path+key)
+				continue
+			}
+			unknown = append(unknown, unknownJSONFields(value, fieldType, path+key+".")...)
+		}
+		sort.Strings(unknown)
+		return unknown
+
+	case reflect.Slice, reflect.Array:
+		var raw []json.RawMessage
+		if json.Unmarshal(data, &raw) != nil {
+			return nil
+		}
+
+		var unknown []string
+		for i, value := range raw {
+			unknown = append(unknown, unknownJSONFields(value, t.Elem(), fmt.Sprintf("%s[%d].", strings.TrimSuffix(path, "."), i))...)
+		}
+		return unknown
+
+	case reflect.Map:
+		var raw map[string]json.RawMessage
+		if json.Unmarshal(data, &raw) != nil {
+			return nil
+		}
+
+		var unknown []string
+		for key, value := range raw {
+			unknown = append(unknown, unknownJSONFields(value, t.Elem(), path+key+".")...)
+		}
+		sort.Strings(unknown)
+		return unknown
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+)
+
+// maxWriterLine is the length after which a line without a newline is logged as it is
+const maxWriterLine = 64 * 1024
+
+// LineWriter logs every line written to it as an entry, the incomplete last line is kept until its newline or
+// until Flush or Close is called
+type LineWriter struct {
+	logger *MultiLogger
+	level  Level
+	buffer []byte
+	mutex  sync.Mutex
+}
+
+var _ io.WriteCloser = (*LineWriter)(nil)
+
+// Writer returns a LineWriter logging each line written to it as an entry at level, for libraries writing to
+// an io.Writer such as exec.Cmd.Stdout. It is safe for concurrent use, empty lines are skipped. Close it once
+// the output ends, such as after exec.Cmd.Wait, otherwise a last line without a newline is never logged.
+func (l *MultiLogger) Writer(level Level) *LineWriter {
+	return &LineWriter{logger: l, level: level}
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.buffer = append(w.buffer, p...)
+	for {
+		i := bytes.IndexByte(w.buffer, '\n')
+		if i < 0 {
+			break
+		}
+		w.logger.logLine(w.level, w.buffer[:i])
+		w.buffer = w.buffer[i+1:]
+	}
+
+	if len(w.buffer) >= maxWriterLine {
+		w.logger.logLine(w.level, w.buffer)
+		w.buffer = nil
+	}
+	if len(w.buffer) == 0 {
+		w.buffer = nil
+	}
+	return len(p), nil
+}
+
+// Flush logs the incomplete last line, if any
+func (w *LineWriter) Flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if len(w.buffer) > 0 {
+		w.logger.logLine(w.level, w.buffer)
+	}
+	w.buffer = nil
+}
+
+// Close logs the incomplete last line, the writer can still be written to afterwards
+func (w *LineWriter) Close() error {
+	w.Flush()
+	return nil
+}
+
+// NewStdLogger returns a *log.Logger writing to l at level, for APIs such as http.Server.ErrorLog
+func NewStdLogger(l *MultiLogger, level Level) *log.Logger {
+	return log.New(l.Writer(level), "", 0)
+}
+
+// RedirectStdLog sends the output of the standard log package to l at level, the returned function restores
+// the previous output, prefix and flags
+func (l *MultiLogger) RedirectStdLog(level Level) func() {
+	writer, prefix, flags := log.Writer(), log.Prefix(), log.Flags()
+
+	log.SetOutput(l.Writer(level))
+	log.SetPrefix("")
+	log.SetFlags(0)
+
+	return func() {
+		log.SetOutput(writer)
+		log.SetPrefix(prefix)
+		log.SetFlags(flags)
+	}
+}
+
+// logLine logs a line written to a Writer, each line is its own sampling template
+func (l *MultiLogger) logLine(level Level, line []byte) {
+	line = bytes.TrimRight(line, "\r")
+	if len(line) == 0 {
+		return
+	}
+
+	if l.stopped.Load() {
+		fallbackLog(ERROR, fmt.Sprintln("Error logging message: ", "logger is stopped ", level))
+		return
+	}
+
+	if !isValidLogLevel(level) {
+		fallbackLog(ERROR, fmt.Sprintln("Error logging message: ", "invalid logger level ", level))
+		return
+	}
+
+	body := string(line)
+	if !l.IsEnabled(level) || !l.allow(level, body) {
+		return
+	}
+
+	l.log(logEntry{level: level, message: formatMessage(level, body), body: body})
+}
@@ -0,0 +1,203 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/grpclogger"
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestGRPCInterceptors(t *testing.T) {
+	serverRecorder := &RecordingLogger{}
+	serverLogger := logger.NewLogger(100, serverRecorder)
+	defer serverLogger.Stop()
+
+	clientRecorder := &RecordingLogger{}
+	clientLogger := logger.NewLogger(100, clientRecorder)
+	defer clientLogger.Stop()
+
+	var handlerFields map[string]interface{}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			grpclogger.UnaryServerInterceptor(serverLogger, grpclogger.Options{}),
+			func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				handlerFields = logger.ContextFields(ctx)
+				return handler(ctx, req)
+			},
+		),
+		grpc.StreamInterceptor(grpclogger.StreamServerInterceptor(serverLogger, grpclogger.Options{})),
+	)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("payments", grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+
+	listener := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(grpclogger.UnaryClientInterceptor(clientLogger, grpclogger.Options{})),
+		grpc.WithStreamInterceptor(grpclogger.StreamClientInterceptor(clientLogger, grpclogger.Options{})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := grpc_health_v1.NewHealthClient(conn)
+
+	ctx := logger.WithContextFields(context.Background(), "request_id", "req-9")
+	ctx = metadata.AppendToOutgoingContext(ctx, "traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	if _, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "payments"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "unknown"}); err == nil {
+		t.Fatal("Expected NotFound for an unknown service")
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	stream, err := client.Watch(watchCtx, &grpc_health_v1.HealthCheckRequest{Service: "payments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	_, _ = stream.Recv()
+
+	time.Sleep(100 * time.Millisecond)
+
+	if handlerFields["request_id"] != "req-9" || handlerFields["grpc_method"] != "Check" {
+		t.Errorf("Expected the handler context to carry the call fields, got %v", handlerFields)
+	}
+
+	serverMessages := serverRecorder.Messages()
+	if len(serverMessages) != 3 {
+		t.Fatalf("Expected 3 server entries, got %d", len(serverMessages))
+	}
+
+	ok, notFound, watch := serverMessages[0], serverMessages[1], serverMessages[2]
+	if ok.Level != "INFO" || ok.Fields["grpc_code"] != "OK" || ok.Fields["grpc_service"] != "grpc.health.v1.Health" || ok.Fields["peer"] == nil {
+		t.Errorf("Unexpected entry of a successful call %+v", ok)
+	}
+	if ok.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the trace id of the traceparent metadata, got %q", ok.TraceID)
+	}
+	if notFound.Level != "WARN" || notFound.Fields["grpc_code"] != codes.NotFound.String() {
+		t.Errorf("Unexpected entry of a failed call %+v", notFound)
+	}
+	if !strings.Contains(watch.Message, "stream call /grpc.health.v1.Health/Watch") || watch.Fields["messages_sent"] != int64(1) {
+		t.Errorf("Unexpected entry of a streaming call %+v", watch)
+	}
+
+	clientMessages := clientRecorder.Messages()
+	if len(clientMessages) != 3 {
+		t.Fatalf("Expected 3 client entries, got %d", len(clientMessages))
+	}
+	if clientMessages[0].Fields["request_id"] != "req-9" || clientMessages[0].Fields["grpc_code"] != "OK" {
+		t.Errorf("Unexpected client entry %+v", clientMessages[0])
+	}
+	if clientMessages[2].Fields["grpc_code"] != codes.Canceled.String() || clientMessages[2].Fields["messages_received"] != int64(1) {
+		t.Errorf("Unexpected client entry of a streaming call %+v", clientMessages[2])
+	}
+}
+
+func TestGRPCClientStreaming(t *testing.T) {
+	clientRecorder := &RecordingLogger{}
+	clientLogger := logger.NewLogger(100, clientRecorder)
+	defer clientLogger.Stop()
+
+	// A client streaming method counting the requests it receives, answered with a single response
+	upload := grpc.StreamDesc{StreamName: "Upload", ClientStreams: true}
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.Uploads",
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    upload.StreamName,
+			ClientStreams: true,
+			Handler: func(_ interface{}, stream grpc.ServerStream) error {
+				for {
+					if err := stream.RecvMsg(&grpc_health_v1.HealthCheckRequest{}); err == io.EOF {
+						return stream.SendMsg(&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING})
+					} else if err != nil {
+						return err
+					}
+				}
+			},
+		}},
+	}, struct{}{})
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("payments", grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+
+	listener := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStreamInterceptor(grpclogger.StreamClientInterceptor(clientLogger, grpclogger.Options{})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	stream, err := conn.NewStream(context.Background(), &upload, "/test.Uploads/Upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := stream.SendMsg(&grpc_health_v1.HealthCheckRequest{Service: "payments"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.RecvMsg(&grpc_health_v1.HealthCheckResponse{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A server stream the caller stops reading is logged when its context is canceled
+	watchCtx, cancel := context.WithCancel(context.Background())
+	watch, err := grpc_health_v1.NewHealthClient(conn).Watch(watchCtx, &grpc_health_v1.HealthCheckRequest{Service: "payments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := watch.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	time.Sleep(100 * time.Millisecond)
+
+	messages := clientRecorder.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 client entries, got %d", len(messages))
+	}
+	if uploaded := messages[0]; !strings.Contains(uploaded.Message, "stream call /test.Uploads/Upload") || uploaded.Fields["grpc_code"] != "OK" ||
+		uploaded.Fields["messages_sent"] != int64(3) || uploaded.Fields["messages_received"] != int64(1) {
+		t.Errorf("Unexpected entry of a client streaming call %+v", uploaded)
+	}
+	if watched := messages[1]; watched.Fields["grpc_code"] != codes.Canceled.String() || watched.Fields["messages_received"] != int64(1) {
+		t.Errorf("Unexpected entry of an abandoned server stream %+v", watched)
+	}
+}
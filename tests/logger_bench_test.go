@@ -0,0 +1,231 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+// benchBufferLen is large enough for the producer not to hit the low priority drop threshold of 0.8*bufferLen
+// in most scenarios, drops are reported as dropped/op
+const benchBufferLen = 100000
+
+// DiscardLogger accepts encoded entries and drops them, after an optional delay simulating a slow sink
+type DiscardLogger struct {
+	Delay time.Duration
+}
+
+func (dl *DiscardLogger) Log(level logger.Level, message string) error {
+	return nil
+}
+
+func (dl *DiscardLogger) LogMessage(level logger.Level, message logger.LogMessage) error {
+	return nil
+}
+
+func (dl *DiscardLogger) LogRaw(level logger.Level, data []byte) error {
+	if dl.Delay > 0 {
+		time.Sleep(dl.Delay)
+	}
+	return nil
+}
+
+func (dl *DiscardLogger) ShouldLogLevel(level logger.Level) bool {
+	return true
+}
+
+func discardSinks(n int, delay time.Duration) []logger.ILogger {
+	sinks := make([]logger.ILogger, n)
+	for i := range sinks {
+		sinks[i] = &DiscardLogger{Delay: delay}
+	}
+	return sinks
+}
+
+// runLoggerBenchmark times b.N calls of logFn until the sinks have written them, so ns/op is the throughput of
+// the whole pipeline and not only of the producer
+func runLoggerBenchmark(b *testing.B, multiLogger *logger.MultiLogger, logFn func(i int)) {
+	defer multiLogger.Stop()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		logFn(i)
+	}
+	multiLogger.Flush(time.Minute)
+
+	b.StopTimer()
+	reportLoggerMetrics(b, multiLogger)
+}
+
+func reportLoggerMetrics(b *testing.B, multiLogger *logger.MultiLogger) {
+	metrics := multiLogger.Metrics()
+	b.ReportMetric(float64(metrics.ChDroppedMessages)/float64(b.N), "dropped/op")
+	b.ReportMetric(float64(metrics.EnqueueLatency.Quantile(0.99).Microseconds()), "enqueue-p99-µs")
+}
+
+func BenchmarkLog(b *testing.B) {
+	for _, sinks := range []int{1, 3, 8} {
+		b.Run(fmt.Sprintf("sinks=%d", sinks), func(b *testing.B) {
+			multiLogger := logger.NewLogger(benchBufferLen, discardSinks(sinks, 0)...)
+			runLoggerBenchmark(b, multiLogger, func(i int) {
+				multiLogger.Log(logger.INFO, "order created", i)
+			})
+		})
+	}
+}
+
+func BenchmarkLogf(b *testing.B) {
+	for _, sinks := range []int{1, 3, 8} {
+		b.Run(fmt.Sprintf("sinks=%d", sinks), func(b *testing.B) {
+			multiLogger := logger.NewLogger(benchBufferLen, discardSinks(sinks, 0)...)
+			runLoggerBenchmark(b, multiLogger, func(i int) {
+				multiLogger.Logf(logger.INFO, "order %d created for %s", i, "customer-42")
+			})
+		})
+	}
+}
+
+func BenchmarkLogContext(b *testing.B) {
+	type requestKey struct{}
+
+	for _, sinks := range []int{1, 3, 8} {
+		b.Run(fmt.Sprintf("sinks=%d", sinks), func(b *testing.B) {
+			multiLogger := logger.NewLogger(benchBufferLen, discardSinks(sinks, 0)...)
+			ctx := logger.WithContextFields(context.WithValue(context.Background(), requestKey{}, "request-1"),
+				"request_id", "4bf92f3577b34da6a3ce929d0e0e4736", "method", "GET", "path", "/orders/42")
+			runLoggerBenchmark(b, multiLogger, func(i int) {
+				multiLogger.LogContext(logger.INFO, ctx, requestKey{})
+			})
+		})
+	}
+}
+
+// BenchmarkCallPath times whole logging calls, from the caller until the sink has the encoded entry, against the
+// same entry logged with log/slog's JSON handler writing synchronously to io.Discard
+func BenchmarkCallPath(b *testing.B) {
+	type requestKey struct{}
+	ctx := logger.WithContextFields(context.WithValue(context.Background(), requestKey{}, "request-1"),
+		"request_id", "4bf92f3577b34da6a3ce929d0e0e4736", "method", "GET", "path", "/orders/42")
+
+	b.Run("Log", func(b *testing.B) {
+		multiLogger := logger.NewLogger(benchBufferLen, discardSinks(1, 0)...)
+		runLoggerBenchmark(b, multiLogger, func(i int) {
+			multiLogger.Log(logger.INFO, "order created", i)
+		})
+	})
+	b.Run("Logf", func(b *testing.B) {
+		multiLogger := logger.NewLogger(benchBufferLen, discardSinks(1, 0)...)
+		runLoggerBenchmark(b, multiLogger, func(i int) {
+			multiLogger.Logf(logger.INFO, "order %d created", i)
+		})
+	})
+	b.Run("LogContext", func(b *testing.B) {
+		multiLogger := logger.NewLogger(benchBufferLen, discardSinks(1, 0)...)
+		runLoggerBenchmark(b, multiLogger, func(i int) {
+			multiLogger.LogContext(logger.INFO, ctx, requestKey{})
+		})
+	})
+	b.Run("LogContextf", func(b *testing.B) {
+		multiLogger := logger.NewLogger(benchBufferLen, discardSinks(1, 0)...)
+		runLoggerBenchmark(b, multiLogger, func(i int) {
+			multiLogger.LogContextf(logger.INFO, ctx, "order %d created", i)
+		})
+	})
+
+	hostname, _ := os.Hostname()
+	slogger := slog.New(slog.NewJSONHandler(io.Discard, nil)).With(slog.Group("tags", "hostname", hostname))
+	b.Run("slog", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			slogger.Info("order created", "order", i)
+		}
+	})
+	b.Run("slog-fields", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			slogger.LogAttrs(ctx, slog.LevelInfo, "order created",
+				slog.Group("fields",
+					slog.String("request_id", "4bf92f3577b34da6a3ce929d0e0e4736"),
+					slog.String("method", "GET"),
+					slog.String("path", "/orders/42"),
+				),
+				slog.Int("order", i),
+			)
+		}
+	})
+}
+
+// BenchmarkSlowSink measures the producer against a sink taking 50µs per entry with the default buffer, the
+// entries it cannot keep up with are dropped
+func BenchmarkSlowSink(b *testing.B) {
+	multiLogger := logger.NewLogger(100, &DiscardLogger{Delay: 50 * time.Microsecond})
+	defer multiLogger.Stop()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		multiLogger.Logf(logger.INFO, "order %d created", i)
+	}
+
+	b.StopTimer()
+	multiLogger.Flush(time.Minute)
+	reportLoggerMetrics(b, multiLogger)
+}
+
+// BenchmarkOverflowParallel logs from parallel producers into a small buffer, dropped/op is the share of
+// entries dropped once the queue holds more than 0.8*bufferLen entries
+func BenchmarkOverflowParallel(b *testing.B) {
+	for _, bufferLen := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("bufferLen=%d", bufferLen), func(b *testing.B) {
+			multiLogger := logger.NewLogger(bufferLen, &DiscardLogger{Delay: time.Microsecond})
+			defer multiLogger.Stop()
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					multiLogger.Logf(logger.INFO, "order %d created", i)
+					i++
+				}
+			})
+
+			b.StopTimer()
+			multiLogger.Flush(time.Minute)
+			reportLoggerMetrics(b, multiLogger)
+			b.ReportMetric(float64(multiLogger.Metrics().ChPeakUsage), "peak-queue")
+		})
+	}
+}
+
+// BenchmarkNATS publishes to an in-process NATS server
+func BenchmarkNATS(b *testing.B) {
+	natsServer, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoLog: true, NoSigs: true})
+	if err != nil {
+		b.Fatalf("Failed to create NATS server: %v", err)
+	}
+	go natsServer.Start()
+	defer natsServer.Shutdown()
+	if !natsServer.ReadyForConnections(5 * time.Second) {
+		b.Fatal("NATS server did not start")
+	}
+
+	natsLogger, err := logger.NewLoggerNATS(natsServer.ClientURL(), logger.DEBUG)
+	if err != nil {
+		b.Fatalf("Failed to connect to NATS: %v", err)
+	}
+	defer natsLogger.Close()
+
+	multiLogger := logger.NewLogger(benchBufferLen, natsLogger)
+	runLoggerBenchmark(b, multiLogger, func(i int) {
+		multiLogger.Logf(logger.INFO, "order %d created for %s", i, "customer-42")
+	})
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+)
+
+func TestCanonicalLine(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	// Without a line in the context every call is a no-op
+	logger.Canonical(context.Background()).Set("ignored", true)
+	logger.Canonical(context.Background()).Emit()
+
+	ctx := logger.WithContextFields(logger.NewContext(context.Background(), multiLogger), "job_id", "job-1")
+	ctx, line := logger.NewCanonicalContext(ctx, "nightly export")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Canonical(ctx).Add("cache_hits", 1)
+			logger.Canonical(ctx).AddDuration("db_time", 2*time.Millisecond)
+		}()
+	}
+	wg.Wait()
+
+	logger.Canonical(ctx).Set("user_tier", "gold")
+	logger.Canonical(ctx).RaiseLevel(logger.WARN)
+	logger.Canonical(ctx).RaiseLevel(logger.DEBUG)
+
+	line.Emit()
+	line.Emit()
+	time.Sleep(50 * time.Millisecond)
+
+	messages := recorder.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected a single entry, got %d", len(messages))
+	}
+
+	fields := messages[0].Fields
+	if messages[0].Level != "WARN" || fields["cache_hits"] != int64(10) || fields["db_time"] != float64(20) ||
+		fields["user_tier"] != "gold" || fields["job_id"] != "job-1" || fields["duration_ms"] == nil {
+		t.Errorf("Unexpected canonical entry %+v", messages[0])
+	}
+}
+
+func TestCanonicalLineNumericTypes(t *testing.T) {
+	_, line := logger.NewCanonicalContext(context.Background(), "job")
+
+	line.Set("cache_hits", 3, "ratio", float32(0.5), "db_time", 2, "state", "done")
+	line.Add("cache_hits", 1)
+	line.Add("ratio", 2)
+	line.AddDuration("db_time", 1500*time.Microsecond)
+	line.Add("state", 1)
+
+	// Values set with Set are added to whatever their numeric type, other values are kept
+	fields := line.Fields()
+	if fields["cache_hits"] != int64(4) || fields["ratio"] != 2.5 || fields["db_time"] != 3.5 || fields["state"] != "done" {
+		t.Errorf("Unexpected fields %v", fields)
+	}
+}
+
+func TestCanonicalHTTP(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	handler := logger.HTTPMiddleware(multiLogger, logger.HTTPOptions{Canonical: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Canonical(r.Context()).Set("user_tier", "free")
+		logger.Canonical(r.Context()).Add("cache_hits", 3)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", nil))
+	time.Sleep(50 * time.Millisecond)
+
+	messages := recorder.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected the access log entry only, got %d entries", len(messages))
+	}
+	fields := messages[0].Fields
+	if fields["status"] != http.StatusAccepted || fields["user_tier"] != "free" || fields["cache_hits"] != int64(3) {
+		t.Errorf("Expected the canonical fields on the access log entry, got %v", fields)
+	}
+}
@@ -2,6 +2,9 @@ package tests
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -37,3 +40,279 @@ func TestLoggerConfiguration(t *testing.T) {
 
 	time.Sleep(2 * time.Second)
 }
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected logger.Level
+		valid    bool
+	}{
+		{"debug", logger.DEBUG, true},
+		{"INFO", logger.INFO, true},
+		{" Warn ", logger.WARN, true},
+		{"warning", logger.WARN, true},
+		{"error", logger.ERROR, true},
+		{"fatal", logger.FATAL, true},
+		{"verbose", logger.UNKNOWN, false},
+	}
+
+	for _, tt := range tests {
+		level, err := logger.ParseLevel(tt.name)
+		if level != tt.expected || (err == nil) != tt.valid {
+			t.Errorf("ParseLevel(%q) = %v, %v, expected %v", tt.name, level, err, tt.expected)
+		}
+	}
+}
+
+func TestConfigurationSinkRegistry(t *testing.T) {
+	recorders := make(map[string]*RecordingLogger)
+
+	logger.RegisterSink("recorder", func(definition logger.SinkDefinition) (logger.ILogger, error) {
+		var settings struct {
+			Prefix string `json:"prefix"`
+		}
+		if err := definition.Decode(&settings); err != nil {
+			return nil, err
+		}
+		recorder := &RecordingLogger{}
+		recorders[settings.Prefix] = recorder
+		return recorder, nil
+	})
+
+	config, err := logger.FromJsonString(`{
+		"version": 2,
+		"sinks": [
+			{"type": "recorder", "name": "primary", "prefix": "a"},
+			{"type": "recorder", "name": "audit", "min_level": "error", "prefix": "b"},
+			{"type": "console", "min_level": "fatal"}
+		],
+		"routing": {"routes": [{"min_level": "error", "sinks": ["audit"]}], "default_sinks": ["primary"]}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	multiLogger := config.Init()
+	defer multiLogger.Stop()
+
+	sinks := multiLogger.Metrics().Sinks
+	if len(sinks) != 3 || sinks[0].Name != "primary" || sinks[1].Name != "audit" || sinks[2].Name != "console" {
+		t.Fatalf("Unexpected sinks %+v", sinks)
+	}
+
+	multiLogger.Logf(logger.INFO, "info %d", 1)
+	multiLogger.Logf(logger.ERROR, "error %d", 2)
+	time.Sleep(10 * time.Millisecond)
+
+	if len(recorders["a"].Messages()) != 1 || len(recorders["b"].Messages()) != 1 {
+		t.Errorf("Expected one message per recorder, got %d and %d", len(recorders["a"].Messages()), len(recorders["b"].Messages()))
+	}
+
+	if marshal, err := json.Marshal(config.Sinks[1]); err != nil || !strings.Contains(string(marshal), `"prefix":"b"`) {
+		t.Errorf("Sink definitions should keep their settings, got %s", marshal)
+	}
+}
+
+func TestConfigurationFlatFormat(t *testing.T) {
+	config, err := logger.FromJsonString(`{"use_console": true, "use_nats": true, "nats_url": "nats://localhost:4222"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	definitions := config.SinkDefinitions()
+	if len(definitions) != 2 || definitions[0].Type != "console" || definitions[1].Type != "nats" {
+		t.Fatalf("Unexpected sink definitions %+v", definitions)
+	}
+	if url, _ := definitions[1].Setting("url"); url != "nats://localhost:4222" {
+		t.Errorf("Expected the NATS url to be translated, got %v", url)
+	}
+
+	if _, err := logger.NewSink(logger.NewSinkDefinition("unknown", "", "", nil)); err == nil {
+		t.Error("Expected an error for an unknown sink type")
+	}
+}
+
+func TestConfigurationValidate(t *testing.T) {
+	config, err := logger.FromJsonString(`{
+		"version": 2,
+		"sinks": [
+			{"type": "console", "min_level": "verbose"},
+			{"type": "nats", "name": "no-url"},
+			{"type": "nats", "name": "down", "url": "nats://127.0.0.1:1"},
+			{"type": "carrier-pigeon"}
+		],
+		"sampling": {"first": 10, "rate_limits": {"loud": {"per_second": 1}}, "thereafer": 5},
+		"use_consol": true
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = config.Validate()
+	if err == nil {
+		t.Fatal("Expected validation errors")
+	}
+
+	for _, expected := range []string{
+		"unknown field use_consol",
+		"unknown field sampling.thereafer",
+		`sink console: invalid log level "verbose"`,
+		"sink no-url: missing url",
+		"sink down: server nats://127.0.0.1:1 unreachable",
+		`unknown sink type "carrier-pigeon"`,
+		`sampling.rate_limits: invalid log level "loud"`,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected validation error %q in:\n%v", expected, err)
+		}
+	}
+
+	// The level is checked once, not again by ValidateSink
+	if n := strings.Count(err.Error(), `invalid log level "verbose"`); n != 1 {
+		t.Errorf("Expected the invalid sink level to be reported once, got %d times in:\n%v", n, err)
+	}
+}
+
+func TestConfigurationInitE(t *testing.T) {
+	config, err := logger.FromJsonString(`{"use_console": true, "use_nats": true, "nats_url": "nats://127.0.0.1:1"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if multiLogger, err := config.InitE(); err == nil || multiLogger != nil {
+		t.Fatal("Expected InitE to fail when the NATS server is unreachable")
+	}
+
+	config.DegradedStart = true
+	config.RetryIntervalSeconds = 1
+
+	multiLogger, err := config.InitE()
+	if err != nil {
+		t.Fatalf("Expected InitE to start in degraded mode, got %v", err)
+	}
+	defer multiLogger.Stop()
+
+	sinks := multiLogger.Metrics().Sinks
+	if len(sinks) != 2 || sinks[1].Name != "nats" {
+		t.Fatalf("Expected the NATS sink to be kept in degraded mode, got %+v", sinks)
+	}
+
+	multiLogger.Log(logger.INFO, "Logged while NATS is unreachable")
+	time.Sleep(10 * time.Millisecond)
+
+	if sinks := multiLogger.Metrics().Sinks; sinks[1].Errors != 0 {
+		t.Errorf("Entries should be buffered while reconnecting, got %+v", sinks[1])
+	}
+}
+
+func TestConfigurationFromEnv(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "nats-password")
+	if err := os.WriteFile(secret, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("LOGGER_USE_CONSOLE", "true")
+	t.Setenv("LOGGER_NATS_URL", "nats://env:4222")
+	t.Setenv("LOGGER_NATS_PASSWORD_FILE", secret)
+	t.Setenv("LOGGER_MIN_LEVEL", "warn")
+	t.Setenv("LOGGER_TAGS", "team=payments, env=prod")
+	t.Setenv("LOGGER_SAMPLING_FIRST", "5")
+	t.Setenv("LOGGER_REDACTION_FIELDS", "ssn,iban")
+
+	config, err := logger.FromEnv("LOGGER")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !config.UseConsole || config.NatsURL != "nats://env:4222" || config.NatsPassword != "s3cret" || config.MinLevel != "warn" {
+		t.Errorf("Unexpected configuration %+v", config)
+	}
+	if config.Tags["team"] != "payments" || config.Tags["env"] != "prod" {
+		t.Errorf("Unexpected tags %v", config.Tags)
+	}
+	if config.Sampling == nil || config.Sampling.First != 5 {
+		t.Errorf("Expected sampling.first to be read, got %+v", config.Sampling)
+	}
+	if config.Redaction == nil || len(config.Redaction.Fields) != 2 || config.Redaction.Fields[1] != "iban" {
+		t.Errorf("Expected redaction.fields to be read, got %+v", config.Redaction)
+	}
+	if config.Routing != nil {
+		t.Error("Expected objects without variables to stay unset")
+	}
+
+	multiLogger := config.Init()
+	defer multiLogger.Stop()
+	if multiLogger.IsEnabled(logger.INFO) || !multiLogger.IsEnabled(logger.WARN) {
+		t.Error("Expected min_level to be applied to the logger")
+	}
+}
+
+func TestConfigurationEnvPrecedence(t *testing.T) {
+	config, err := logger.FromJsonString(`{"use_console": true, "nats_url": "nats://file:4222", "min_level": "debug", "service_name": "file"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("APP_NATS_URL", "nats://env:4222")
+	t.Setenv("APP_MIN_LEVEL", "error")
+
+	if err := config.ApplyEnv("APP_"); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.NatsURL != "nats://env:4222" || config.MinLevel != "error" {
+		t.Errorf("Expected the environment to override the file, got %+v", config)
+	}
+	if !config.UseConsole || config.ServiceName != "file" {
+		t.Errorf("Expected values without variables to be kept, got %+v", config)
+	}
+}
+
+func TestConfigurationEnvErrors(t *testing.T) {
+	t.Setenv("LOGGER_USE_NATS", "maybe")
+	t.Setenv("LOGGER_DEDUP_WINDOW_SECONDS", "ten")
+	t.Setenv("LOGGER_TAGS", "team")
+	t.Setenv("LOGGER_NATS_PASSWORD", "inline")
+	t.Setenv("LOGGER_NATS_PASSWORD_FILE", "/run/secrets/nats")
+
+	_, err := logger.FromEnv("LOGGER")
+	if err == nil {
+		t.Fatal("Expected an error for invalid variables")
+	}
+
+	for _, expected := range []string{"LOGGER_USE_NATS", "LOGGER_DEDUP_WINDOW_SECONDS", "LOGGER_TAGS", "LOGGER_NATS_PASSWORD_FILE"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %s to be reported, got %v", expected, err)
+		}
+	}
+}
+
+func TestConfigurationDuplicateSinkNames(t *testing.T) {
+	config, err := logger.FromJsonString(`{"sinks": [
+		{"type": "console"},
+		{"type": "console"},
+		{"type": "console", "name": "audit"},
+		{"type": "console", "name": "audit"},
+		{"type": "console", "name": "other"}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = config.Validate()
+	if err == nil {
+		t.Fatal("Expected duplicate sink names to be rejected")
+	}
+	for _, expected := range []string{"sink console: duplicate sink name", "sink audit: duplicate sink name"} {
+		if strings.Count(err.Error(), expected) != 1 {
+			t.Errorf("Expected validation error %q once in:\n%v", expected, err)
+		}
+	}
+	if strings.Contains(err.Error(), "sink other") {
+		t.Errorf("Unexpected error for a unique name:\n%v", err)
+	}
+
+	if _, err := config.InitE(); err == nil {
+		t.Error("Expected InitE to reject duplicate sink names")
+	}
+}
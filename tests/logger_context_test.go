@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+)
+
+func TestContextLogger(t *testing.T) {
+	if logger.FromContext(context.Background()) != logger.Logger {
+		t.Error("Expected the package logger without a logger in the context")
+	}
+
+	multiLogger := logger.NewLogger(100, &RecordingLogger{})
+	defer multiLogger.Stop()
+
+	ctx := logger.NewContext(context.Background(), multiLogger)
+	if logger.FromContext(ctx) != multiLogger {
+		t.Error("Expected the logger carried by the context")
+	}
+}
+
+func TestContextFields(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	ctx := logger.WithContextFields(context.Background(), "request_id", "req-1", "tenant", "acme")
+	child := logger.WithContextFields(ctx, "user", 42, "tenant", "globex", 7)
+
+	if len(logger.ContextFields(ctx)) != 2 {
+		t.Errorf("Expected the parent fields to be left unchanged, got %v", logger.ContextFields(ctx))
+	}
+
+	multiLogger.LogContextf(logger.INFO, child, "charged %d items", 3)
+	multiLogger.LogContext(logger.INFO, ctx)
+	time.Sleep(50 * time.Millisecond)
+
+	messages := recorder.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+
+	fields := messages[0].Fields
+	if !strings.HasSuffix(messages[0].Message, "charged 3 items") {
+		t.Errorf("Unexpected message %q", messages[0].Message)
+	}
+	if fields["request_id"] != "req-1" || fields["tenant"] != "globex" || fields["user"] != 42 || fields[logger.BadKey] != 7 {
+		t.Errorf("Unexpected fields %v", fields)
+	}
+	if messages[1].Fields["tenant"] != "acme" {
+		t.Errorf("Expected the fields of the parent context, got %v", messages[1].Fields)
+	}
+}
+
+// PlainMultiLogger implements logger.IMultiLogger without LogContextf, as implementations written before it
+type PlainMultiLogger struct {
+	formats []string
+}
+
+func (pl *PlainMultiLogger) Log(level logger.Level, args ...interface{}) {}
+
+func (pl *PlainMultiLogger) Logf(level logger.Level, format string, args ...interface{}) {
+	pl.formats = append(pl.formats, format)
+}
+
+func (pl *PlainMultiLogger) LogJson(level logger.Level, args ...interface{}) {}
+
+func (pl *PlainMultiLogger) LogContext(level logger.Level, ctx context.Context, keys ...interface{}) {
+}
+
+func (pl *PlainMultiLogger) Stop() {}
+
+func TestContextLoggerWithoutLogContextf(t *testing.T) {
+	var _ logger.IContextLogger = &logger.MultiLogger{}
+
+	plain := &PlainMultiLogger{}
+	ctx, _ := logger.NewCanonicalContext(logger.NewContext(context.Background(), plain), "job")
+	logger.Canonical(ctx).Emit()
+
+	// Loggers without LogContextf get the canonical line through Logf
+	if len(plain.formats) != 1 || !strings.Contains(plain.formats[0], "completed in") {
+		t.Errorf("Expected the canonical line through Logf, got %v", plain.formats)
+	}
+}
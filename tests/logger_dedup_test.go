@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+)
+
+func TestDedup(t *testing.T) {
+	recorder := &RecordingLogger{}
+
+	multiLogger := logger.NewLoggerWithOptions(100, []logger.ILogger{recorder}, logger.WithDedup(40*time.Millisecond))
+	defer multiLogger.Stop()
+
+	for i := 0; i < 5; i++ {
+		multiLogger.Logf(logger.WARN, "connection to %s refused", "db:5432")
+	}
+	multiLogger.Logf(logger.WARN, "connection to %s refused", "cache:6379")
+	time.Sleep(10 * time.Millisecond)
+
+	if messages := recorder.Messages(); len(messages) != 2 {
+		t.Fatalf("Expected the first occurrence of each message only, got %d", len(messages))
+	}
+
+	time.Sleep(80 * time.Millisecond)
+
+	messages := recorder.Messages()
+	if len(messages) != 3 {
+		t.Fatalf("Expected one repeat summary after the window closed, got %d messages", len(messages))
+	}
+
+	summary := messages[2]
+	if summary.Fields["repeat_count"] != int64(4) {
+		t.Errorf("Expected repeat_count 4, got %v", summary.Fields["repeat_count"])
+	}
+	if summary.Fields["first_seen"] == nil || summary.Fields["last_seen"] == nil {
+		t.Errorf("Expected first_seen and last_seen fields, got %v", summary.Fields)
+	}
+	if deduplicated := multiLogger.Metrics().DeduplicatedMessages; deduplicated != 4 {
+		t.Errorf("Expected 4 deduplicated messages, got %d", deduplicated)
+	}
+}
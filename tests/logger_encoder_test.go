@@ -0,0 +1,231 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	internallogger "github.com/CoreKitMDK/corekit-service-logger/v2/internal/logger"
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+)
+
+// RawRecordingLogger records the entries written through LogRaw
+type RawRecordingLogger struct {
+	RecordingLogger
+	raw   [][]byte
+	mutex sync.Mutex
+}
+
+func (rl *RawRecordingLogger) LogRaw(level logger.Level, data []byte) error {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	rl.raw = append(rl.raw, append([]byte(nil), data...))
+	return nil
+}
+
+func (rl *RawRecordingLogger) Raw() [][]byte {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	return append([][]byte(nil), rl.raw...)
+}
+
+func benchmarkMessage() logger.LogMessage {
+	return logger.LogMessage{
+		Timestamp: "2025-01-02T15:04:05Z",
+		Level:     "INFO",
+		Message:   "2025-01-02 15:04:05 - [INFO] : GET /orders/42 200 512B 3.2ms",
+		Tags:      map[string]string{"hostname": "orders-7d9f", "service_name": "orders", "namespace": "shop"},
+		Fields: map[string]interface{}{
+			"request_id":  "4bf92f3577b34da6a3ce929d0e0e4736",
+			"method":      "GET",
+			"path":        "/orders/42",
+			"status":      200,
+			"bytes":       int64(512),
+			"duration_ms": 3.2,
+			"cached":      false,
+		},
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:  "00f067aa0ba902b7",
+	}
+}
+
+func TestAppendLogMessage(t *testing.T) {
+	messages := []logger.LogMessage{
+		{},
+		benchmarkMessage(),
+		{
+			Level:   "ERROR",
+			Message: "quotes \" backslash \\ html <a href=\"x\">&</a> control \x00\x1f\b\f\n\r\t unicode é 日本 \u2028\u2029",
+			Tags:    map[string]string{},
+			Fields: map[string]interface{}{
+				"nil":       nil,
+				"int8":      int8(-8),
+				"uint":      uint(7),
+				"uint64":    uint64(math.MaxUint64),
+				"int64":     int64(math.MinInt64),
+				"float32":   float32(0.1),
+				"small":     1e-7,
+				"large":     1e21,
+				"negative":  -123.456,
+				"zero":      0.0,
+				"duration":  1500 * time.Millisecond,
+				"time":      time.Date(2025, 1, 2, 3, 4, 5, 6, time.UTC),
+				"strings":   []string{"a", "<b>"},
+				"nilslice":  []string(nil),
+				"list":      []interface{}{1, "two", 3.5, map[string]interface{}{"z": 1, "a": []interface{}{true}}},
+				"nested":    map[string]interface{}{"b": map[string]string{"y": "1", "x": "2"}, "a": nil},
+				"struct":    struct{ Name string }{"n"},
+				"bytes":     []byte("raw"),
+				"empty key": "",
+			},
+			TraceFlags: "01",
+		},
+	}
+
+	for i, message := range messages {
+		expected, err := json.Marshal(message)
+		if err != nil {
+			t.Fatalf("Message %d: %v", i, err)
+		}
+		encoded, err := logger.AppendLogMessage([]byte("prefix"), &message)
+		if err != nil {
+			t.Fatalf("Message %d: unexpected error %v", i, err)
+		}
+		if !bytes.Equal(encoded[len("prefix"):], expected) || string(encoded[:len("prefix")]) != "prefix" {
+			t.Errorf("Message %d:\n got %s\nwant %s", i, encoded, expected)
+		}
+	}
+
+	// Invalid UTF-8 is replaced by U+FFFD
+	replaced := logger.LogMessage{Message: "invalid \xff\xfe"}
+	encoded, _ := logger.AppendLogMessage(nil, &replaced)
+	if err := json.Unmarshal(encoded, &replaced); err != nil || replaced.Message != "invalid \ufffd\ufffd" {
+		t.Errorf("Expected invalid UTF-8 to be replaced, got %s (%v)", encoded, err)
+	}
+
+	invalid := logger.LogMessage{Fields: map[string]interface{}{"nan": math.NaN()}}
+	if _, err := logger.AppendLogMessage(nil, &invalid); err == nil {
+		t.Error("Expected NaN to be rejected like json.Marshal")
+	}
+}
+
+func TestAppendLogMessageAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops buffers at random under the race detector")
+	}
+	message := benchmarkMessage()
+	buffer := make([]byte, 0, 4096)
+
+	allocations := testing.AllocsPerRun(100, func() {
+		buffer, _ = logger.AppendLogMessage(buffer[:0], &message)
+	})
+	if allocations > 0 {
+		t.Errorf("Expected no allocations, got %v", allocations)
+	}
+}
+
+func TestRawSinksShareEncoding(t *testing.T) {
+	first, second := &RawRecordingLogger{}, &RawRecordingLogger{}
+	plain := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, first, second, plain)
+	defer multiLogger.Stop()
+
+	multiLogger.LogContextf(logger.INFO, logger.WithContextFields(nil, "order_id", 42), "order %s", "created")
+	multiLogger.Flush(time.Second)
+
+	if len(first.Messages()) != 0 || len(first.Raw()) != 1 || len(second.Raw()) != 1 {
+		t.Fatalf("Expected the raw sinks to receive the encoded entry only, got %d %d", len(first.Raw()), len(second.Raw()))
+	}
+	if !bytes.Equal(first.Raw()[0], second.Raw()[0]) {
+		t.Errorf("Expected the same encoding, got %s and %s", first.Raw()[0], second.Raw()[0])
+	}
+
+	messages := plain.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected the plain sink to receive the entry, got %d", len(messages))
+	}
+	var decoded logger.LogMessage
+	if err := json.Unmarshal(first.Raw()[0], &decoded); err != nil || decoded.Message != messages[0].Message || decoded.Fields["order_id"] != float64(42) {
+		t.Errorf("Expected the encoding of the entry, got %s (%v)", first.Raw()[0], err)
+	}
+}
+
+func TestLogArgsEncoding(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	cyclic := &node{Name: "loop"}
+	cyclic.Next = cyclic
+	argsList := [][]interface{}{
+		{"order created", 42, int64(-7), uint8(3), 3.25, float32(0.1), true, nil},
+		{"<tag> & \"quotes\"\n", []string{"a", "b"}, map[string]interface{}{"b": 1, "a": []interface{}{"x", 2.5}}},
+		{customer{Name: "John", Email: "john@example.com", Card: card{Holder: "John", Number: "4111"}}},
+		{masked{Secret: "s3cret"}, time.Duration(1500), map[string]string{"z": "1", "a": "2"}},
+		{math.NaN()},
+		{*cyclic},
+	}
+
+	// The arguments are written as Stringify wrote them with json.Marshal
+	for _, args := range argsList {
+		multiLogger.Log(logger.INFO, args...)
+	}
+	multiLogger.Flush(time.Second)
+
+	messages := recorder.Messages()
+	if len(messages) != len(argsList) {
+		t.Fatalf("Expected %d messages, got %d", len(argsList), len(messages))
+	}
+	for i, args := range argsList {
+		expected := internallogger.Stringify(args) + "\n"
+		if !strings.HasSuffix(messages[i].Message, expected) {
+			t.Errorf("Message %d:\n got %q\nwant suffix %q", i, messages[i].Message, expected)
+		}
+	}
+}
+
+func BenchmarkAppendLogMessage(b *testing.B) {
+	message := benchmarkMessage()
+	buffer := make([]byte, 0, 4096)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		buffer, _ = logger.AppendLogMessage(buffer[:0], &message)
+	}
+}
+
+func BenchmarkJSONMarshalLogMessage(b *testing.B) {
+	message := benchmarkMessage()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = json.Marshal(message)
+	}
+}
+
+func BenchmarkSlogJSONHandler(b *testing.B) {
+	handler := slog.New(slog.NewJSONHandler(io.Discard, nil)).With(
+		slog.Group("tags", "hostname", "orders-7d9f", "service_name", "orders", "namespace", "shop"),
+	)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		handler.LogAttrs(nil, slog.LevelInfo, "2025-01-02 15:04:05 - [INFO] : GET /orders/42 200 512B 3.2ms",
+			slog.String("request_id", "4bf92f3577b34da6a3ce929d0e0e4736"),
+			slog.String("method", "GET"),
+			slog.String("path", "/orders/42"),
+			slog.Int("status", 200),
+			slog.Int64("bytes", 512),
+			slog.Float64("duration_ms", 3.2),
+			slog.Bool("cached", false),
+			slog.String("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736"),
+			slog.String("span_id", "00f067aa0ba902b7"),
+		)
+	}
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+)
+
+func TestEnvironmentTags(t *testing.T) {
+	podInfo := t.TempDir()
+	files := map[string]string{
+		"name":      "payments-7d9f-abcde\n",
+		"namespace": "shop\n",
+		"labels":    "app.kubernetes.io/name=\"payments\"\napp.kubernetes.io/version=\"1.4.2\"\npod-template-hash=\"7d9f\"\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(podInfo, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("POD_NAMESPACE", "shop-staging")
+	t.Setenv("NODE_NAME", "node-1")
+	t.Setenv("CONTAINER_NAME", "")
+	t.Setenv("K8S_CONTAINER_NAME", "")
+
+	tags := logger.EnvironmentTags(podInfo)
+
+	expected := map[string]string{
+		"pod_name":        "payments-7d9f-abcde",
+		"namespace":       "shop-staging",
+		"node_name":       "node-1",
+		"service_name":    "payments",
+		"service_version": "1.4.2",
+	}
+	for key, value := range expected {
+		if tags[key] != value {
+			t.Errorf("Expected tag %s=%s, got %q", key, value, tags[key])
+		}
+	}
+	if _, ok := tags["container_name"]; ok {
+		t.Error("Expected tags without a value to be left out")
+	}
+	if tags["module"] == "" {
+		t.Error("Expected the module path from the build info")
+	}
+
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLoggerWithOptions(100, []logger.ILogger{recorder},
+		logger.WithEnvironmentTags(podInfo), logger.WithTags(map[string]string{"namespace": "override"}))
+	defer multiLogger.Stop()
+
+	multiLogger.Log(logger.INFO, "tagged")
+	time.Sleep(50 * time.Millisecond)
+
+	messages := recorder.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+	if messages[0].Tags["service_name"] != "payments" || messages[0].Tags["namespace"] != "override" || messages[0].Tags["hostname"] == "" {
+		t.Errorf("Unexpected tags %v", messages[0].Tags)
+	}
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+)
+
+// MockPublisher implements the logger.IPublisher interface for testing
+type MockPublisher struct {
+	mutex    sync.Mutex
+	subjects []string
+	payloads [][]byte
+}
+
+func (mp *MockPublisher) Publish(subject string, data []byte) error {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+	mp.subjects = append(mp.subjects, subject)
+	mp.payloads = append(mp.payloads, data)
+	return nil
+}
+
+func (mp *MockPublisher) Last() (string, []byte, int) {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+	if len(mp.payloads) == 0 {
+		return "", nil, 0
+	}
+	return mp.subjects[len(mp.subjects)-1], mp.payloads[len(mp.payloads)-1], len(mp.payloads)
+}
+
+func TestHeartbeat(t *testing.T) {
+	publisher := &MockPublisher{}
+	mock := NewMockLogger(logger.DEBUG)
+
+	multiLogger := logger.NewLoggerWithOptions(10, []logger.ILogger{mock}, logger.WithHeartbeat(publisher, logger.HeartbeatOptions{
+		Service:  "payments",
+		Version:  "1.2.3",
+		Interval: 20 * time.Millisecond,
+	}))
+
+	multiLogger.Log(logger.INFO, "Test message")
+	time.Sleep(50 * time.Millisecond)
+
+	subject, payload, count := publisher.Last()
+	if count < 2 {
+		t.Fatalf("Expected periodic heartbeats, got %d", count)
+	}
+	if subject != logger.DefaultHeartbeatSubject {
+		t.Errorf("Expected subject %s, got %s", logger.DefaultHeartbeatSubject, subject)
+	}
+
+	var hb logger.Heartbeat
+	if err := json.Unmarshal(payload, &hb); err != nil {
+		t.Fatalf("Heartbeat is not valid JSON: %v", err)
+	}
+	if hb.Service != "payments" || hb.Version != "1.2.3" || hb.Hostname == "" {
+		t.Errorf("Unexpected heartbeat identity %+v", hb)
+	}
+	if hb.TotalMessages != 1 || len(hb.Sinks) != 1 || hb.Sinks[0].Messages != 1 {
+		t.Errorf("Unexpected heartbeat counters %+v", hb)
+	}
+
+	multiLogger.Stop()
+	time.Sleep(10 * time.Millisecond)
+
+	_, payload, _ = publisher.Last()
+	if err := json.Unmarshal(payload, &hb); err != nil || !hb.Stopping {
+		t.Errorf("Expected a final heartbeat marked as stopping, got %s", payload)
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+)
+
+func TestTransport(t *testing.T) {
+	var receivedRequestID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedRequestID = r.Header.Get("X-Request-ID")
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	client := &http.Client{Transport: logger.NewTransport(multiLogger, nil, logger.TransportOptions{LogHeaders: true})}
+	ctx := logger.WithContextFields(context.Background(), "request_id", "req-7")
+
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/items?page=2&api_key=abc123", nil)
+	request.Header.Set("Authorization", "Bearer secret-token")
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = response.Body.Close()
+
+	if receivedRequestID != "req-7" {
+		t.Errorf("Expected the request ID to be propagated, got %q", receivedRequestID)
+	}
+	if request.Header.Get("X-Request-ID") != "" {
+		t.Error("Expected the original request to be left unchanged")
+	}
+
+	request, _ = http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/fail", nil)
+	response, err = client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = response.Body.Close()
+
+	request, _ = http.NewRequestWithContext(ctx, http.MethodGet, "http://127.0.0.1:1/unreachable", nil)
+	if _, err := client.Do(request); err == nil {
+		t.Fatal("Expected the call to an unreachable server to fail")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	messages := recorder.Messages()
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(messages))
+	}
+
+	success := messages[0]
+	if success.Level != "DEBUG" || success.Fields["status"] != 200 || success.Fields["path"] != "/items" || success.Fields["request_id"] != "req-7" {
+		t.Errorf("Unexpected entry of a successful call %+v", success)
+	}
+	if query := success.Fields["query"].(string); strings.Contains(query, "abc123") || !strings.Contains(query, "page=2") {
+		t.Errorf("Expected the api_key parameter to be redacted, got %q", query)
+	}
+	if headers := success.Fields["headers"].(map[string]string); headers["Authorization"] != "[REDACTED]" {
+		t.Errorf("Expected the Authorization header to be redacted, got %v", headers)
+	}
+
+	if messages[1].Level != "WARN" || messages[1].Fields["status"] != 502 {
+		t.Errorf("Unexpected entry of a server error %+v", messages[1])
+	}
+	if messages[2].Level != "ERROR" || messages[2].Fields["error"] == nil {
+		t.Errorf("Unexpected entry of a failed call %+v", messages[2])
+	}
+}
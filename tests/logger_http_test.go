@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).(logger.IContextLogger).LogContextf(logger.INFO, r.Context(), "loading order %s", r.PathValue("id"))
+		_, _ = w.Write([]byte("order"))
+	})
+	mux.HandleFunc("GET /panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	handler := logger.HTTPMiddleware(multiLogger, logger.HTTPOptions{})(mux)
+
+	request := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	request.Header.Set("X-Request-ID", "req-42")
+	request.Header.Set("User-Agent", "test-agent")
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	if response.Header().Get("X-Request-ID") != "req-42" {
+		t.Errorf("Expected the incoming request ID to be propagated, got %q", response.Header().Get("X-Request-ID"))
+	}
+
+	panicResponse := httptest.NewRecorder()
+	handler.ServeHTTP(panicResponse, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if panicResponse.Code != http.StatusInternalServerError {
+		t.Errorf("Expected a recovered panic to return 500, got %d", panicResponse.Code)
+	}
+	if len(panicResponse.Header().Get("X-Request-ID")) != 32 {
+		t.Errorf("Expected a generated request ID, got %q", panicResponse.Header().Get("X-Request-ID"))
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	messages := recorder.Messages()
+	if len(messages) != 4 {
+		t.Fatalf("Expected 4 messages, got %d", len(messages))
+	}
+
+	handlerEntry, accessEntry := messages[0], messages[1]
+	if !strings.HasSuffix(handlerEntry.Message, "loading order 42") || handlerEntry.Fields["request_id"] != "req-42" || handlerEntry.Fields["user_agent"] != "test-agent" {
+		t.Errorf("Expected the handler entry to carry the request fields, got %+v", handlerEntry)
+	}
+	if accessEntry.Level != "INFO" || accessEntry.Fields["status"] != 200 || accessEntry.Fields["bytes"] != int64(5) ||
+		accessEntry.Fields["route"] != "GET /orders/{id}" || accessEntry.Fields["method"] != "GET" {
+		t.Errorf("Unexpected access log entry %+v", accessEntry)
+	}
+	if _, ok := accessEntry.Fields["duration_ms"]; !ok {
+		t.Error("Expected the access log entry to carry the duration")
+	}
+
+	panicEntry, panicAccessEntry := messages[2], messages[3]
+	if panicEntry.Level != "ERROR" || !strings.Contains(panicEntry.Message, "boom") || !strings.Contains(panicEntry.Message, "Stack trace") {
+		t.Errorf("Expected the panic to be logged with its stack, got %+v", panicEntry)
+	}
+	if panicAccessEntry.Level != "ERROR" || panicAccessEntry.Fields["status"] != 500 || panicAccessEntry.Fields["panicked"] != true {
+		t.Errorf("Unexpected access log entry of the panic %+v", panicAccessEntry)
+	}
+}
+
+func TestHTTPMiddlewarePanicAfterHeader(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	handler := logger.HTTPMiddleware(multiLogger, logger.HTTPOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("late boom")
+	}))
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodPost, "/jobs", nil))
+	multiLogger.Flush(time.Second)
+
+	// The client received 202, the access log reports it along with the panic
+	messages := recorder.Messages()
+	if response.Code != http.StatusAccepted || len(messages) != 2 {
+		t.Fatalf("Expected the sent status and 2 messages, got %d and %d", response.Code, len(messages))
+	}
+	if access := messages[1]; access.Level != "ERROR" || access.Fields["status"] != http.StatusAccepted || access.Fields["panicked"] != true {
+		t.Errorf("Unexpected access log entry of the panic %+v", access)
+	}
+}
+
+func TestHTTPMiddlewareHijack(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	// An upgrader asserting http.Hijacker, as websocket libraries do
+	server := httptest.NewServer(logger.HTTPMiddleware(multiLogger, logger.HTTPOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "not a hijacker", http.StatusInternalServerError)
+			return
+		}
+		conn, rw, err := hijacker.Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n")
+		_ = rw.Flush()
+	})))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	status, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || !strings.Contains(status, "101") {
+		t.Fatalf("Expected the upgrade to succeed behind the middleware, got %q (%v)", status, err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	multiLogger.Flush(time.Second)
+
+	messages := recorder.Messages()
+	if len(messages) != 1 || messages[0].Fields["hijacked"] != true {
+		t.Errorf("Expected an access log entry of the hijacked connection, got %+v", messages)
+	}
+}
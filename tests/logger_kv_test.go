@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+	"github.com/nats-io/nats.go"
+)
+
+func newTestBucket(t *testing.T) (*nats.Conn, nats.KeyValue) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.UserInfo("internal-logger-broker", "internal-logger-broker"))
+	if err != nil {
+		t.Skipf("NATS server not available: %v", err)
+	}
+	t.Cleanup(nc.Close)
+
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bucket := fmt.Sprintf("logger-config-test-%d", time.Now().UnixNano())
+	kv, err := js.CreateKeyValue(&nats.KeyValueConfig{Bucket: bucket})
+	if err != nil {
+		t.Skipf("JetStream not available: %v", err)
+	}
+	t.Cleanup(func() { _ = js.DeleteKeyValue(bucket) })
+
+	return nc, kv
+}
+
+func TestFromKV(t *testing.T) {
+	nc, kv := newTestBucket(t)
+
+	if _, err := kv.PutString("default", `{"use_console": true, "min_level": "warn"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := logger.FromKV(nc, logger.KVOptions{Bucket: kv.Bucket(), Key: "payments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.UseConsole || config.MinLevel != "warn" {
+		t.Errorf("Expected the default key to be used, got %+v", config)
+	}
+
+	if _, err := kv.PutString("payments", "use_console: true\nmin_level: error\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err = logger.FromKV(nc, logger.KVOptions{Bucket: kv.Bucket(), Key: "payments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.MinLevel != "error" {
+		t.Errorf("Expected the service key to be preferred, got %+v", config)
+	}
+
+	if _, err := logger.FromKV(nc, logger.KVOptions{Bucket: kv.Bucket(), Key: "orders", DefaultKey: "missing"}); err == nil {
+		t.Error("Expected an error when no key is found")
+	}
+}
+
+func TestKVWatcher(t *testing.T) {
+	nc, kv := newTestBucket(t)
+
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	if _, err := kv.PutString("default", `{"min_level": "warn"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := logger.NewKVWatcher(multiLogger, nc, logger.KVOptions{Bucket: kv.Bucket(), Key: "payments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Stop()
+
+	waitFor := func(description string, condition func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !condition() {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s", description)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	waitFor("the default configuration", func() bool {
+		return !multiLogger.IsEnabled(logger.INFO) && multiLogger.IsEnabled(logger.WARN)
+	})
+
+	if _, err := kv.PutString("payments", `{"min_level": "error", "tags": {"team": "payments"}}`); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("the service configuration", func() bool { return !multiLogger.IsEnabled(logger.WARN) })
+
+	if err := kv.Delete("payments"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("the fallback to the default configuration", func() bool { return multiLogger.IsEnabled(logger.WARN) })
+
+	if _, err := kv.PutString("default", `{"min_level": "loud"}`); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if !multiLogger.IsEnabled(logger.WARN) || multiLogger.IsEnabled(logger.INFO) {
+		t.Error("Expected an invalid entry to keep the current configuration")
+	}
+
+	origins := 0
+	for _, message := range recorder.Messages() {
+		if strings.Contains(message.Message, "Applied logger configuration from NATS KV "+kv.Bucket()) {
+			origins++
+		}
+	}
+	if origins != 3 {
+		t.Errorf("Expected the origin of the 3 applied configurations to be logged, got %d", origins)
+	}
+}
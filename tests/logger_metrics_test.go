@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+)
+
+func TestHistogram(t *testing.T) {
+	h := logger.NewHistogram(10*time.Microsecond, 50*time.Microsecond, 100*time.Microsecond)
+
+	for i := 1; i <= 100; i++ {
+		h.Observe(time.Duration(i) * time.Microsecond)
+	}
+	h.Observe(time.Millisecond)
+
+	s := h.Snapshot()
+	if s.Count != 101 {
+		t.Errorf("Expected 101 observations, got %d", s.Count)
+	}
+	if s.Min != time.Microsecond || s.Max != time.Millisecond {
+		t.Errorf("Unexpected min/max %v/%v", s.Min, s.Max)
+	}
+	if len(s.Counts) != 4 || s.Counts[0] != 10 || s.Counts[1] != 40 || s.Counts[2] != 50 || s.Counts[3] != 1 {
+		t.Errorf("Unexpected bucket counts %v", s.Counts)
+	}
+
+	if p50 := s.Quantile(0.5); p50 < 45*time.Microsecond || p50 > 55*time.Microsecond {
+		t.Errorf("Expected p50 around 50µs, got %v", p50)
+	}
+	if p99 := s.Quantile(0.99); p99 < 50*time.Microsecond || p99 > 100*time.Microsecond {
+		t.Errorf("Expected p99 in the 50µs-100µs bucket, got %v", p99)
+	}
+
+	h.Reset()
+	if h.Snapshot().Count != 0 {
+		t.Error("Histogram should be empty after reset")
+	}
+}
+
+func TestMetricsSnapshotLatency(t *testing.T) {
+	mock := NewMockLogger(logger.DEBUG)
+
+	// Two sinks, the enqueue latency is still observed once per entry
+	multiLogger := logger.NewLoggerWithOptions(10, []logger.ILogger{mock, NewMockLogger(logger.DEBUG)}, logger.WithLatencyBuckets(time.Microsecond, time.Millisecond))
+	defer multiLogger.Stop()
+
+	for i := 0; i < 5; i++ {
+		multiLogger.Logf(logger.INFO, "message %d", i)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	snapshot := multiLogger.Metrics()
+	if snapshot.EnqueueLatency.Count != 5 {
+		t.Errorf("Expected 5 enqueue latency observations, got %d", snapshot.EnqueueLatency.Count)
+	}
+	if len(snapshot.EnqueueLatency.Buckets) != 2 {
+		t.Errorf("Expected configured buckets, got %v", snapshot.EnqueueLatency.Buckets)
+	}
+	if len(snapshot.Sinks) != 2 || snapshot.Sinks[0].Name != "sink-0" {
+		t.Fatalf("Unexpected sinks in snapshot %+v", snapshot.Sinks)
+	}
+	if snapshot.Sinks[0].WriteLatency.Count != 5 {
+		t.Errorf("Expected 5 write latency observations, got %d", snapshot.Sinks[0].WriteLatency.Count)
+	}
+}
+
+func TestMetricsPerSink(t *testing.T) {
+	working := NewMockLogger(logger.DEBUG)
+	failing := NewMockLogger(logger.DEBUG)
+	failing.shouldFail = true
+
+	multiLogger := logger.NewLogger(10, working, failing)
+	defer multiLogger.Stop()
+
+	multiLogger.Logf(logger.WARN, "warning %d", 1)
+	multiLogger.Logf(logger.ERROR, "error %d", 2)
+	time.Sleep(10 * time.Millisecond)
+
+	snapshot := multiLogger.Metrics()
+	if snapshot.ChTotalMessages != 2 || snapshot.WarnCount != 1 || snapshot.ErrorCount != 1 {
+		t.Errorf("Unexpected counters %+v", snapshot)
+	}
+	if snapshot.LoggerFailedCount != 2 {
+		t.Errorf("Expected 2 logger failures, got %d", snapshot.LoggerFailedCount)
+	}
+	if len(snapshot.Sinks) != 2 {
+		t.Fatalf("Expected 2 sinks, got %d", len(snapshot.Sinks))
+	}
+
+	ok, bad := snapshot.Sinks[0], snapshot.Sinks[1]
+	if ok.Messages != 2 || ok.Bytes == 0 || ok.Errors != 0 {
+		t.Errorf("Unexpected working sink metrics %+v", ok)
+	}
+	if bad.Messages != 0 || bad.Errors != 2 || bad.LastError != "mock logger failed intentionally" || bad.LastErrorTime.IsZero() {
+		t.Errorf("Unexpected failing sink metrics %+v", bad)
+	}
+
+	multiLogger.ResetMetrics()
+	snapshot = multiLogger.Metrics()
+	if snapshot.ChTotalMessages != 0 || snapshot.Sinks[1].Errors != 0 || snapshot.EnqueueLatency.Count != 0 {
+		t.Errorf("Metrics should be cleared after reset %+v", snapshot)
+	}
+	if snapshot.AliveSince.IsZero() {
+		t.Error("AliveSince should be kept after reset")
+	}
+}
+
+func TestMetricsSinkBytes(t *testing.T) {
+	raw, plain := &RawRecordingLogger{}, &RecordingLogger{}
+	multiLogger := logger.NewLogger(10, raw, plain)
+	defer multiLogger.Stop()
+
+	multiLogger.LogContextf(logger.INFO, logger.WithContextFields(nil, "order_id", 42), "order %s", "created")
+	multiLogger.Flush(time.Second)
+
+	// The raw sink is counted by the encoded entry it received, with tags, fields and framing
+	snapshot := multiLogger.Metrics()
+	if len(raw.Raw()) != 1 || len(plain.Messages()) != 1 {
+		t.Fatalf("Expected both sinks to receive the entry, got %d %d", len(raw.Raw()), len(plain.Messages()))
+	}
+	if encoded := int64(len(raw.Raw()[0])); snapshot.Sinks[0].Bytes != encoded {
+		t.Errorf("Expected the raw sink bytes to be %d, got %d", encoded, snapshot.Sinks[0].Bytes)
+	}
+	if message := int64(len(plain.Messages()[0].Message)); snapshot.Sinks[1].Bytes != message {
+		t.Errorf("Expected the plain sink bytes to be %d, got %d", message, snapshot.Sinks[1].Bytes)
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+)
+
+func TestFlush(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+
+	for i := 0; i < 20; i++ {
+		multiLogger.Logf(logger.INFO, "entry %d", i)
+	}
+	if !multiLogger.Flush(time.Second) {
+		t.Fatal("Expected the flush to complete")
+	}
+	if messages := recorder.Messages(); len(messages) != 20 {
+		t.Errorf("Expected the queued entries to be written, got %d", len(messages))
+	}
+
+	multiLogger.Stop()
+	if multiLogger.Flush(time.Second) {
+		t.Error("Expected the flush of a stopped logger to fail")
+	}
+}
+
+func TestRecoverAndLog(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLoggerWithOptions(100, []logger.ILogger{recorder}, logger.WithMinLevel(logger.ERROR))
+	defer multiLogger.Stop()
+
+	func() {
+		defer multiLogger.RecoverAndLog(logger.ContinueOnPanic())
+		multiLogger.Logf(logger.ERROR, "before the %s", "panic")
+		panicInWorker("boom")
+	}()
+
+	// The panic entry is written before RecoverAndLog returns
+	messages := recorder.Messages()
+	if len(messages) != 2 || !strings.Contains(messages[0].Message, "before the panic") {
+		t.Fatalf("Expected the queued entry and the panic, got %+v", messages)
+	}
+	panicEntry := messages[1]
+	if panicEntry.Level != "FATAL" || panicEntry.Fields["panic"] != "boom" || !strings.Contains(panicEntry.Message, "panicInWorker") {
+		t.Errorf("Expected the panic value and the stack of the panicking goroutine, got %+v", panicEntry)
+	}
+}
+
+func TestRecoverAndLogRepanics(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	recovered := func() (recovered interface{}) {
+		defer func() { recovered = recover() }()
+		defer multiLogger.RecoverAndLog()
+		panicInWorker("again")
+		return nil
+	}()
+
+	if recovered != "again" {
+		t.Errorf("Expected the panic to be raised again, got %v", recovered)
+	}
+	if messages := recorder.Messages(); len(messages) != 1 || messages[0].Level != "FATAL" {
+		t.Errorf("Expected the panic entry, got %+v", messages)
+	}
+}
+
+func TestGo(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	done := make(chan struct{})
+	logger.Go(multiLogger, func() {
+		defer close(done)
+		panicInWorker(42)
+	}, logger.ContinueOnPanic())
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the goroutine to end")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(recorder.Messages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if messages := recorder.Messages(); len(messages) != 1 || messages[0].Fields["panic"] != "42" {
+		t.Errorf("Expected the panic of the goroutine, got %+v", messages)
+	}
+}
+
+func panicInWorker(value interface{}) {
+	panic(value)
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+)
+
+func TestProcessors(t *testing.T) {
+	recorder := &RecordingLogger{}
+
+	multiLogger := logger.NewLoggerWithOptions(10, []logger.ILogger{recorder}, logger.WithProcessors(
+		logger.AddFields(map[string]interface{}{"region": "eu-west-1"}),
+		logger.AddTags(map[string]string{"team": "payments"}),
+		logger.RenameKeys(map[string]string{"hostname": "host"}),
+		logger.DropIf(func(message *logger.LogMessage) bool {
+			return strings.Contains(message.Message, "healthz")
+		}),
+	))
+	defer multiLogger.Stop()
+
+	multiLogger.Logf(logger.INFO, "GET %s", "/healthz")
+	multiLogger.Logf(logger.INFO, "GET %s", "/orders")
+	time.Sleep(10 * time.Millisecond)
+
+	messages := recorder.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected the health check to be dropped, got %d messages", len(messages))
+	}
+
+	message := messages[0]
+	if message.Fields["region"] != "eu-west-1" {
+		t.Errorf("Expected region field, got %v", message.Fields)
+	}
+	if message.Tags["team"] != "payments" || message.Tags["host"] == "" {
+		t.Errorf("Expected team and host tags, got %v", message.Tags)
+	}
+	if _, ok := message.Tags["hostname"]; ok {
+		t.Errorf("Expected hostname to be renamed, got %v", message.Tags)
+	}
+	if dropped := multiLogger.Metrics().ProcessorDroppedMessages; dropped != 1 {
+		t.Errorf("Expected 1 dropped message, got %d", dropped)
+	}
+}
+
+func TestProcessorsDoNotShareTags(t *testing.T) {
+	recorder := &RecordingLogger{}
+
+	multiLogger := logger.NewLoggerWithOptions(10, []logger.ILogger{recorder}, logger.WithProcessors(
+		logger.ProcessorFunc(func(message *logger.LogMessage) bool {
+			message.Tags["request"] = message.Message
+			return true
+		}),
+		logger.RenameKeys(map[string]string{"hostname": "host"}),
+	))
+	defer multiLogger.Stop()
+
+	multiLogger.Logf(logger.INFO, "first %d", 1)
+	multiLogger.Logf(logger.INFO, "second %d", 2)
+	time.Sleep(10 * time.Millisecond)
+
+	messages := recorder.Messages()
+	if len(messages) != 2 || messages[0].Tags["request"] == messages[1].Tags["request"] {
+		t.Errorf("Each message should get its own tags, got %+v", messages)
+	}
+	if messages[1].Tags["host"] == "" {
+		t.Errorf("Logger tags should not be modified by processors, got %v", messages[1].Tags)
+	}
+}
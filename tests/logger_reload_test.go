@@ -0,0 +1,397 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+)
+
+// reloadRecorders keeps the recorders built by the "reload-recorder" sink type, keyed by their prefix setting
+var (
+	reloadRecorders      = make(map[string][]*RecordingLogger)
+	reloadRecordersMutex sync.Mutex
+)
+
+func init() {
+	logger.RegisterSink("reload-recorder", func(definition logger.SinkDefinition) (logger.ILogger, error) {
+		prefix, _ := definition.Setting("prefix")
+		recorder := &RecordingLogger{}
+
+		reloadRecordersMutex.Lock()
+		defer reloadRecordersMutex.Unlock()
+		reloadRecorders[prefix.(string)] = append(reloadRecorders[prefix.(string)], recorder)
+		return recorder, nil
+	})
+}
+
+// ClosingRecorder is a RecordingLogger that fails once it is closed
+type ClosingRecorder struct {
+	RecordingLogger
+	closed atomic.Bool
+}
+
+func (cr *ClosingRecorder) LogMessage(level logger.Level, message logger.LogMessage) error {
+	if cr.closed.Load() {
+		return fmt.Errorf("sink is closed")
+	}
+	return cr.RecordingLogger.LogMessage(level, message)
+}
+
+func (cr *ClosingRecorder) Close() {
+	cr.closed.Store(true)
+}
+
+var (
+	closingRecorders      []*ClosingRecorder
+	closingRecordersMutex sync.Mutex
+)
+
+func init() {
+	logger.RegisterSink("closing-recorder", func(definition logger.SinkDefinition) (logger.ILogger, error) {
+		recorder := &ClosingRecorder{}
+		// A slow build makes concurrent reloads overlap
+		time.Sleep(time.Millisecond)
+
+		closingRecordersMutex.Lock()
+		defer closingRecordersMutex.Unlock()
+		closingRecorders = append(closingRecorders, recorder)
+		return recorder, nil
+	})
+}
+
+func builtRecorders(prefix string) []*RecordingLogger {
+	reloadRecordersMutex.Lock()
+	defer reloadRecordersMutex.Unlock()
+	return reloadRecorders[prefix]
+}
+
+func TestFromFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.json": `{"use_console": true, "min_level": "warn", "tags": {"team": "payments"}}`,
+		"config.yaml": "use_console: true\nmin_level: warn\ntags:\n  team: payments\n",
+		"config.toml": "use_console = true\nmin_level = \"warn\"\n[tags]\nteam = \"payments\"\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		config, err := logger.FromFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !config.UseConsole || config.MinLevel != "warn" || config.Tags["team"] != "payments" {
+			t.Errorf("%s: unexpected configuration %+v", name, config)
+		}
+	}
+
+	yamlConfig, err := logger.FromYamlString("use_console: true\nverbose: true\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := yamlConfig.Validate(); err == nil || !strings.Contains(err.Error(), "verbose") {
+		t.Errorf("Expected the unknown YAML field to be reported, got %v", err)
+	}
+
+	if _, err := logger.FromFile(filepath.Join(dir, "config.ini")); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestReconfigure(t *testing.T) {
+	builtKept, builtChanged := len(builtRecorders("reconfigure-kept")), len(builtRecorders("reconfigure-changed"))
+	config, err := logger.FromJsonString(`{"sinks": [
+		{"type": "reload-recorder", "name": "kept", "prefix": "reconfigure-kept"},
+		{"type": "reload-recorder", "name": "changed", "prefix": "reconfigure-changed"}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	multiLogger, err := config.InitE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer multiLogger.Stop()
+
+	multiLogger.Log(logger.DEBUG, "before reload")
+	time.Sleep(50 * time.Millisecond)
+
+	next, err := logger.FromJsonString(`{"min_level": "info", "tags": {"team": "payments"}, "sinks": [
+		{"type": "reload-recorder", "name": "kept", "prefix": "reconfigure-kept"},
+		{"type": "reload-recorder", "name": "changed", "prefix": "reconfigure-changed", "min_level": "warn"}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := multiLogger.Reconfigure(next); err != nil {
+		t.Fatal(err)
+	}
+
+	multiLogger.Log(logger.DEBUG, "filtered")
+	multiLogger.Log(logger.INFO, "after reload")
+	time.Sleep(100 * time.Millisecond)
+
+	kept := builtRecorders("reconfigure-kept")[builtKept:]
+	changed := builtRecorders("reconfigure-changed")[builtChanged:]
+	if len(kept) != 1 || len(changed) != 2 {
+		t.Fatalf("Expected only the changed sink to be built again, got %d and %d", len(kept), len(changed))
+	}
+
+	messages := kept[0].Messages()
+	if len(messages) != 2 {
+		t.Fatalf("Expected the kept sink to receive 2 messages, got %d", len(messages))
+	}
+	if !strings.Contains(messages[0].Message, "before reload") || messages[0].Tags["team"] != "" {
+		t.Errorf("Unexpected first message %+v", messages[0])
+	}
+	if !strings.Contains(messages[1].Message, "after reload") || messages[1].Tags["team"] != "payments" {
+		t.Errorf("Expected the new tags on the second message, got %+v", messages[1])
+	}
+	if multiLogger.Configuration() != next {
+		t.Error("Expected the applied configuration to be returned")
+	}
+
+	invalid, _ := logger.FromJsonString(`{"min_level": "loud", "sinks": [{"type": "reload-recorder", "name": "kept", "prefix": "reconfigure-kept"}]}`)
+	if err := multiLogger.Reconfigure(invalid); err == nil {
+		t.Error("Expected an invalid configuration to be rejected")
+	}
+	if multiLogger.IsEnabled(logger.DEBUG) || !multiLogger.IsEnabled(logger.INFO) {
+		t.Error("Expected the previous configuration to be kept after a failed reload")
+	}
+}
+
+func TestReconfigureKeepsOptions(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLoggerWithOptions(100, []logger.ILogger{recorder},
+		logger.WithTags(map[string]string{"region": "eu"}), logger.WithRedactor(logger.NewRedactor()))
+	defer multiLogger.Stop()
+
+	next, err := logger.FromJsonString(`{"min_level": "info", "tags": {"team": "payments"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := multiLogger.Reconfigure(next); err != nil {
+		t.Fatal(err)
+	}
+
+	multiLogger.Log(logger.DEBUG, "filtered")
+	multiLogger.Logf(logger.INFO, "contact %s", "jane@example.com")
+	multiLogger.Flush(time.Second)
+
+	// The configuration applies on top of the options given in code
+	messages := recorder.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+	if messages[0].Tags["region"] != "eu" || messages[0].Tags["team"] != "payments" {
+		t.Errorf("Expected the tags of the options and the configuration, got %v", messages[0].Tags)
+	}
+	if strings.Contains(messages[0].Message, "jane@example.com") {
+		t.Errorf("Expected the redactor of the options to be kept, got %s", messages[0].Message)
+	}
+}
+
+// BlockingLogger blocks every write until release is closed
+type BlockingLogger struct {
+	RecordingLogger
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (bl *BlockingLogger) LogMessage(level logger.Level, message logger.LogMessage) error {
+	bl.writing <- struct{}{}
+	<-bl.release
+	return bl.RecordingLogger.LogMessage(level, message)
+}
+
+func TestReconfigureDuringSlowWrite(t *testing.T) {
+	sink := &BlockingLogger{writing: make(chan struct{}, 10), release: make(chan struct{})}
+	multiLogger := logger.NewLogger(100, sink)
+	defer multiLogger.Stop()
+
+	multiLogger.Log(logger.INFO, "slow")
+	<-sink.writing
+
+	next, err := logger.FromJsonString(`{"min_level": "debug"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reconfigured := make(chan error, 1)
+	go func() { reconfigured <- multiLogger.Reconfigure(next) }()
+	time.Sleep(50 * time.Millisecond)
+
+	// Neither the reload nor the callers wait for the sink
+	logged := make(chan struct{})
+	go func() {
+		multiLogger.Log(logger.INFO, "while writing")
+		close(logged)
+	}()
+	select {
+	case <-logged:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Log not to wait for the sink write")
+	}
+	select {
+	case err := <-reconfigured:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Reconfigure not to wait for the sink write")
+	}
+
+	close(sink.release)
+	multiLogger.Flush(time.Second)
+	if len(sink.Messages()) != 2 {
+		t.Errorf("Expected 2 messages, got %d", len(sink.Messages()))
+	}
+}
+
+func TestConfigWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logger.yaml")
+	// Files are replaced atomically, as Kubernetes does, so the watcher never reads a partial file
+	write := func(content string) {
+		if err := os.WriteFile(path+".tmp", []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(path+".tmp", path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	built := len(builtRecorders("watcher"))
+	write("sinks:\n  - type: reload-recorder\n    name: watched\n    prefix: watcher\n")
+	config, err := logger.FromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	multiLogger := config.Init()
+	defer multiLogger.Stop()
+
+	watcher := logger.NewConfigWatcher(multiLogger, path, logger.WatchOptions{Interval: 20 * time.Millisecond, IgnoreSIGHUP: true})
+	defer watcher.Stop()
+
+	write("min_level: error\nsinks:\n  - type: reload-recorder\n    name: watched\n    prefix: watcher\n")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for multiLogger.IsEnabled(logger.WARN) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if multiLogger.IsEnabled(logger.WARN) {
+		t.Fatal("Expected the changed file to be applied")
+	}
+
+	write("min_level: verbose\n")
+	time.Sleep(200 * time.Millisecond)
+	if multiLogger.IsEnabled(logger.WARN) || !multiLogger.IsEnabled(logger.ERROR) {
+		t.Error("Expected an invalid file to keep the current configuration")
+	}
+
+	if recorders := builtRecorders("watcher"); len(recorders)-built != 1 {
+		t.Errorf("Expected the unchanged sink to be kept, %d were built", len(recorders)-built)
+	}
+}
+
+func TestReconfigureConcurrent(t *testing.T) {
+	closingRecordersMutex.Lock()
+	built := len(closingRecorders)
+	closingRecordersMutex.Unlock()
+
+	configs := make([]*logger.Configuration, 2)
+	for i, level := range []string{"debug", "info"} {
+		config, err := logger.FromJsonString(fmt.Sprintf(`{"sinks": [{"type": "closing-recorder", "name": "closing", "min_level": %q}]}`, level))
+		if err != nil {
+			t.Fatal(err)
+		}
+		configs[i] = config
+	}
+
+	multiLogger, err := configs[0].InitE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer multiLogger.Stop()
+
+	// Every call changes the sink definition, so each one builds a sink and closes the previous one
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := multiLogger.Reconfigure(configs[(i+1)%2]); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	multiLogger.Log(logger.WARN, "after reloads")
+	multiLogger.Flush(time.Second)
+
+	closingRecordersMutex.Lock()
+	var open []*ClosingRecorder
+	for _, recorder := range closingRecorders[built:] {
+		if !recorder.closed.Load() {
+			open = append(open, recorder)
+		}
+	}
+	closingRecordersMutex.Unlock()
+
+	if len(open) != 1 || len(open[0].Messages()) != 1 {
+		t.Fatalf("Expected a single installed sink left open, got %d", len(open))
+	}
+	if sinks := multiLogger.Metrics().Sinks; len(sinks) != 1 || sinks[0].Errors != 0 {
+		t.Errorf("Expected the installed sink not to be closed, got %+v", sinks)
+	}
+}
+
+func TestReconfigureWithoutSinks(t *testing.T) {
+	closingRecordersMutex.Lock()
+	built := len(closingRecorders)
+	closingRecordersMutex.Unlock()
+
+	config, err := logger.FromJsonString(`{"sinks": [{"type": "closing-recorder", "name": "closing"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	multiLogger, err := config.InitE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer multiLogger.Stop()
+
+	// A reloaded file or ConfigMap that only sets a level keeps the running sinks
+	levelOnly, err := logger.FromJsonString(`{"min_level": "warn"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := multiLogger.Reconfigure(levelOnly); err != nil {
+		t.Fatal(err)
+	}
+	if err := multiLogger.Reconfigure(config); err != nil {
+		t.Fatal(err)
+	}
+
+	multiLogger.Log(logger.WARN, "after reloads")
+	multiLogger.Flush(time.Second)
+
+	closingRecordersMutex.Lock()
+	recorders := closingRecorders[built:]
+	closingRecordersMutex.Unlock()
+
+	// The inherited definition is compared with the next one, so the sink is neither closed nor built again
+	if len(recorders) != 1 || recorders[0].closed.Load() || len(recorders[0].Messages()) != 1 {
+		t.Fatalf("Expected the original sink to be kept open and receive the entry, got %d sinks", len(recorders))
+	}
+}
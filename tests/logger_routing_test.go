@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+)
+
+// NamedRecorder is a RecordingLogger with a sink name used by routing
+type NamedRecorder struct {
+	RecordingLogger
+	name string
+}
+
+func (nr *NamedRecorder) Name() string {
+	return nr.name
+}
+
+func TestRouting(t *testing.T) {
+	console := &NamedRecorder{name: "console"}
+	audit := &NamedRecorder{name: "audit"}
+	access := &NamedRecorder{name: "access"}
+
+	config, err := logger.FromJsonString(`{
+		"routing": {
+			"routes": [
+				{"name": "security", "tags": {"category": "security"}, "sinks": ["audit"], "continue": true},
+				{"name": "security-errors", "tags": {"category": "security"}, "min_level": "error", "sinks": ["console"]},
+				{"name": "http", "component": "http", "message": "GET /", "sinks": ["access"]}
+			],
+			"default_sinks": ["console"]
+		}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Routing.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	multiLogger := logger.NewLoggerWithOptions(10, []logger.ILogger{console, audit, access},
+		logger.WithProcessors(logger.ProcessorFunc(func(message *logger.LogMessage) bool {
+			if strings.HasSuffix(message.Message, "login failed") || strings.HasSuffix(message.Message, "account locked") {
+				message.Fields["category"] = "security"
+			}
+			if strings.HasSuffix(message.Message, "/orders") {
+				message.Fields["component"] = "http"
+			}
+			return true
+		})),
+		logger.WithRouting(*config.Routing),
+	)
+	defer multiLogger.Stop()
+
+	for _, m := range []struct {
+		level   logger.Level
+		message string
+	}{
+		{logger.WARN, "login failed"},
+		{logger.FATAL, "account locked"},
+		{logger.INFO, "GET /orders"},
+		{logger.INFO, "POST /orders"},
+		{logger.INFO, "started"},
+	} {
+		multiLogger.Logf(m.level, "%s", m.message)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	expect := func(name string, recorder *NamedRecorder, messages ...string) {
+		got := recorder.Messages()
+		if len(got) != len(messages) {
+			t.Errorf("Expected %d messages in %s, got %d", len(messages), name, len(got))
+			return
+		}
+		for i, m := range messages {
+			if !strings.HasSuffix(got[i].Message, m) {
+				t.Errorf("Expected %q in %s, got %q", m, name, got[i].Message)
+			}
+		}
+	}
+
+	expect("audit", audit, "login failed", "account locked")
+	expect("access", access, "GET /orders")
+	expect("console", console, "account locked", "POST /orders", "started")
+}
+
+func TestRoutingValidate(t *testing.T) {
+	routing := logger.Routing{Routes: []logger.Route{
+		{Name: "bad-level", MinLevel: "verbose", Sinks: []string{"console"}},
+		{Name: "bad-regex", Message: "(", Sinks: []string{"console"}},
+		{Name: "no-sinks"},
+	}}
+
+	if err := routing.Validate(); err == nil {
+		t.Error("Expected invalid routes to be reported")
+	}
+}
+
+func TestRoutingUnknownSinks(t *testing.T) {
+	config, err := logger.FromJsonString(`{
+		"sinks": [{"type": "console"}, {"type": "console", "name": "audit"}],
+		"routing": {
+			"routes": [
+				{"name": "security", "tags": {"category": "security"}, "sinks": ["audti", "console"]},
+				{"message": "GET /", "sinks": ["access"]}
+			],
+			"default_sinks": ["consol"]
+		}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = config.Validate()
+	if err == nil {
+		t.Fatal("Expected unknown sinks to be reported")
+	}
+	for _, expected := range []string{
+		`route security: unknown sink "audti"`,
+		`route #1: unknown sink "access"`,
+		`default_sinks: unknown sink "consol"`,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected validation error %q in:\n%v", expected, err)
+		}
+	}
+	if strings.Contains(err.Error(), `"console"`) {
+		t.Errorf("Unexpected error for a defined sink:\n%v", err)
+	}
+
+	// Without sinks the configuration keeps those of the running logger, the names are not checked
+	config.Sinks = nil
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected no error without sinks, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+)
+
+func TestSampler(t *testing.T) {
+	recorder := &RecordingLogger{}
+
+	multiLogger := logger.NewLoggerWithOptions(100, []logger.ILogger{recorder},
+		logger.WithSampler(logger.SamplerOptions{Interval: time.Minute, First: 3, Thereafter: 5}),
+		logger.WithSamplingSummaryInterval(30*time.Millisecond),
+	)
+	defer multiLogger.Stop()
+
+	for i := 0; i < 20; i++ {
+		multiLogger.Logf(logger.INFO, "connection to %s failed", "db")
+	}
+	multiLogger.Logf(logger.INFO, "another %s", "template")
+	multiLogger.Flush(time.Second)
+
+	// 3 first entries, then the 8th, 13th and 18th, plus the other template
+	if n := len(recorder.Messages()); n != 7 {
+		t.Errorf("Expected 7 sampled entries, got %d", n)
+	}
+	if suppressed := multiLogger.Metrics().SuppressedMessages; suppressed != 14 {
+		t.Errorf("Expected 14 suppressed entries, got %d", suppressed)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	multiLogger.Flush(time.Second)
+
+	var summary bool
+	for _, message := range recorder.Messages() {
+		summary = summary || strings.Contains(message.Message, "Sampling suppressed 14 messages")
+	}
+	if !summary {
+		t.Errorf("Expected a summary of suppressed messages, got %+v", recorder.Messages())
+	}
+}
+
+func TestSamplerByCaller(t *testing.T) {
+	recorder := &RecordingLogger{}
+
+	multiLogger := logger.NewLoggerWithOptions(100, []logger.ILogger{recorder},
+		logger.WithSampler(logger.SamplerOptions{Interval: time.Minute, First: 1, Key: logger.SampleByCaller}),
+	)
+	defer multiLogger.Stop()
+
+	for i := 0; i < 5; i++ {
+		multiLogger.Logf(logger.INFO, "message %d", i)
+		multiLogger.Log(logger.INFO, "other call site")
+	}
+	multiLogger.Flush(time.Second)
+
+	if n := len(recorder.Messages()); n != 2 {
+		t.Errorf("Expected one entry per call site, got %d", n)
+	}
+}
+
+func TestSamplerLogContext(t *testing.T) {
+	recorder := &RecordingLogger{}
+
+	multiLogger := logger.NewLoggerWithOptions(100, []logger.ILogger{recorder},
+		logger.WithSampler(logger.SamplerOptions{Interval: time.Minute, First: 1}),
+	)
+	defer multiLogger.Stop()
+
+	type requestKey struct{}
+	type userKey struct{}
+	ctx := context.WithValue(context.WithValue(context.Background(), requestKey{}, "request-1"), userKey{}, "user-1")
+
+	// LogContext has no template, each call site gets its own sampling budget
+	for i := 0; i < 5; i++ {
+		multiLogger.LogContext(logger.INFO, ctx, requestKey{})
+		multiLogger.LogContext(logger.INFO, ctx, userKey{})
+	}
+	multiLogger.Flush(time.Second)
+
+	messages := recorder.Messages()
+	if len(messages) != 2 || !strings.Contains(messages[0].Message, "request-1") || !strings.Contains(messages[1].Message, "user-1") {
+		t.Errorf("Expected one entry per LogContext call site, got %+v", messages)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	recorder := &RecordingLogger{}
+
+	multiLogger := logger.NewLoggerWithOptions(100, []logger.ILogger{recorder},
+		logger.WithRateLimit(logger.DEBUG, 0, 2),
+	)
+	defer multiLogger.Stop()
+
+	for i := 0; i < 10; i++ {
+		multiLogger.Logf(logger.DEBUG, "debug %d", i)
+	}
+	multiLogger.Logf(logger.INFO, "info %d", 1)
+	multiLogger.Flush(time.Second)
+
+	if n := len(recorder.Messages()); n != 3 {
+		t.Errorf("Expected 2 rate limited DEBUG entries and 1 INFO entry, got %d", n)
+	}
+}
@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+)
+
+func TestScopeDiscardsOnSuccess(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	ctx, scope := logger.NewScopeContext(context.Background(), 10)
+	multiLogger.LogContextf(logger.DEBUG, ctx, "debug %d", 1)
+	multiLogger.LogContextf(logger.INFO, ctx, "info %d", 2)
+	multiLogger.LogContextf(logger.WARN, ctx, "warn %d", 3)
+	scope.End()
+	multiLogger.LogContextf(logger.INFO, ctx, "after end")
+	time.Sleep(50 * time.Millisecond)
+
+	messages := recorder.Messages()
+	if len(messages) != 2 || !strings.Contains(messages[0].Message, "warn 3") || !strings.Contains(messages[1].Message, "after end") {
+		t.Fatalf("Expected only the WARN entry and the entry after End, got %+v", messages)
+	}
+	if scope.Failed() {
+		t.Error("Expected the scope not to fail")
+	}
+}
+
+func TestScopeFlushesOnError(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	ctx, scope := logger.NewScopeContext(context.Background(), 3)
+	for i := 0; i < 5; i++ {
+		multiLogger.LogContextf(logger.DEBUG, ctx, "step %d", i)
+	}
+	first := time.Now()
+	time.Sleep(1100 * time.Millisecond)
+
+	multiLogger.LogContextf(logger.ERROR, ctx, "failed")
+	multiLogger.LogContextf(logger.DEBUG, ctx, "cleanup")
+	scope.End()
+	time.Sleep(50 * time.Millisecond)
+
+	messages := recorder.Messages()
+	expected := []string{"earlier entries were dropped", "step 2", "step 3", "step 4", "failed", "cleanup"}
+	if len(messages) != len(expected) {
+		t.Fatalf("Expected %d entries, got %+v", len(expected), messages)
+	}
+	for i, message := range messages {
+		if !strings.Contains(message.Message, expected[i]) {
+			t.Errorf("Entry %d: expected %q, got %q", i, expected[i], message.Message)
+		}
+	}
+
+	// Held entries keep the time they were logged at
+	if messages[1].Timestamp != first.Format(time.RFC3339) && messages[1].Timestamp != first.Add(-time.Second).Format(time.RFC3339) {
+		t.Errorf("Expected the original timestamp, got %s", messages[1].Timestamp)
+	}
+	if messages[1].Timestamp == messages[4].Timestamp {
+		t.Errorf("Expected the held entry to be older than the error, got %s", messages[1].Timestamp)
+	}
+	if !scope.Failed() {
+		t.Error("Expected the scope to fail")
+	}
+}
+
+func TestScopeFlushSkipsNearFullDrop(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(10, recorder)
+	defer multiLogger.Stop()
+
+	// The held entries are many more than the 8 low priority entries the queue accepts once near full
+	ctx, _ := logger.NewScopeContext(context.Background(), logger.DefaultScopeCapacity)
+	for i := 0; i < logger.DefaultScopeCapacity; i++ {
+		multiLogger.LogContextf(logger.INFO, ctx, "step %d", i)
+	}
+	multiLogger.LogContextf(logger.ERROR, ctx, "failed")
+	if !multiLogger.Flush(5 * time.Second) {
+		t.Fatal("Expected the flushed entries to be written")
+	}
+
+	messages := recorder.Messages()
+	if len(messages) != logger.DefaultScopeCapacity+1 {
+		t.Fatalf("Expected %d entries, got %d", logger.DefaultScopeCapacity+1, len(messages))
+	}
+	for i := 0; i < logger.DefaultScopeCapacity; i++ {
+		if !strings.Contains(messages[i].Message, fmt.Sprintf("step %d", i)) {
+			t.Fatalf("Entry %d: expected step %d, got %q", i, i, messages[i].Message)
+		}
+	}
+}
+
+func TestScopeFlushBoundedWait(t *testing.T) {
+	sink := &BlockingLogger{writing: make(chan struct{}, 100), release: make(chan struct{})}
+	multiLogger := logger.NewLogger(1, sink)
+	defer multiLogger.Stop()
+	defer close(sink.release)
+
+	// The sink does not write, the queue of 10 entries fills up during the flush
+	ctx, _ := logger.NewScopeContext(context.Background(), 50)
+	for i := 0; i < 50; i++ {
+		multiLogger.LogContextf(logger.INFO, ctx, "step %d", i)
+	}
+
+	start := time.Now()
+	multiLogger.LogContextf(logger.ERROR, ctx, "failed")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the flush to give up on a blocked sink, it took %v", elapsed)
+	}
+	if dropped := multiLogger.Metrics().ChDroppedMessages; dropped == 0 {
+		t.Error("Expected the entries that did not fit to be counted as dropped")
+	}
+}
+
+func TestScopeHTTP(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	handler := logger.HTTPMiddleware(multiLogger, logger.HTTPOptions{BufferedEntries: 10})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).(logger.IContextLogger).LogContextf(logger.DEBUG, r.Context(), "handling %s", r.URL.Path)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	time.Sleep(50 * time.Millisecond)
+	if messages := recorder.Messages(); len(messages) != 1 || messages[0].Fields["status"] != 200 {
+		t.Fatalf("Expected only the access log of the successful request, got %+v", messages)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	time.Sleep(50 * time.Millisecond)
+	messages := recorder.Messages()
+	if len(messages) != 3 || !strings.Contains(messages[1].Message, "handling /fail") || messages[2].Level != "ERROR" {
+		t.Fatalf("Expected the held entry before the access log of the failed request, got %+v", messages)
+	}
+}
@@ -8,12 +8,14 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
 // MockLogger implements the logger.ILogger interface for testing
 type MockLogger struct {
+	mutex        sync.Mutex
 	buffer       bytes.Buffer
 	minLogLevel  logger.Level
 	shouldFail   bool
@@ -29,10 +31,13 @@ func NewMockLogger(minLogLevel logger.Level) *MockLogger {
 }
 
 func (ml *MockLogger) LogMessage(level logger.Level, message logger.LogMessage) error {
-	return nil
+	return ml.Log(level, message.Message)
 }
 
 func (ml *MockLogger) Log(level logger.Level, message string) error {
+	ml.mutex.Lock()
+	defer ml.mutex.Unlock()
+
 	ml.loggedCalls++
 	if ml.shouldFail {
 		return fmt.Errorf("mock logger failed intentionally")
@@ -42,19 +47,60 @@ func (ml *MockLogger) Log(level logger.Level, message string) error {
 }
 
 func (ml *MockLogger) ShouldLogLevel(level logger.Level) bool {
+	ml.mutex.Lock()
+	defer ml.mutex.Unlock()
+
 	ml.levelChecked = level
 	return level >= ml.minLogLevel
 }
 
 func (ml *MockLogger) GetLoggedContent() string {
+	ml.mutex.Lock()
+	defer ml.mutex.Unlock()
 	return ml.buffer.String()
 }
 
+func (ml *MockLogger) LoggedCalls() int {
+	ml.mutex.Lock()
+	defer ml.mutex.Unlock()
+	return ml.loggedCalls
+}
+
 func (ml *MockLogger) ResetBuffer() {
+	ml.mutex.Lock()
+	defer ml.mutex.Unlock()
+
 	ml.buffer.Reset()
 	ml.loggedCalls = 0
 }
 
+// RecordingLogger implements the logger.ILogger interface and keeps every LogMessage it receives
+type RecordingLogger struct {
+	mutex    sync.Mutex
+	messages []logger.LogMessage
+}
+
+func (rl *RecordingLogger) LogMessage(level logger.Level, message logger.LogMessage) error {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	rl.messages = append(rl.messages, message)
+	return nil
+}
+
+func (rl *RecordingLogger) Log(level logger.Level, message string) error {
+	return rl.LogMessage(level, logger.LogMessage{Level: logger.LogLevelToString(level), Message: message})
+}
+
+func (rl *RecordingLogger) ShouldLogLevel(level logger.Level) bool {
+	return true
+}
+
+func (rl *RecordingLogger) Messages() []logger.LogMessage {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	return append([]logger.LogMessage(nil), rl.messages...)
+}
+
 func TestLoggerConsole(t *testing.T) {
 	// Redirect stdout to capture console output
 	oldStdout := os.Stdout
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// RecordingSpan is a recording span that keeps the names and attributes of its events
+type RecordingSpan struct {
+	noop.Span
+	spanContext trace.SpanContext
+
+	mutex  sync.Mutex
+	events []trace.EventConfig
+}
+
+func (s *RecordingSpan) IsRecording() bool {
+	return true
+}
+
+func (s *RecordingSpan) SpanContext() trace.SpanContext {
+	return s.spanContext
+}
+
+func (s *RecordingSpan) AddEvent(name string, options ...trace.EventOption) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.events = append(s.events, trace.NewEventConfig(options...))
+}
+
+func (s *RecordingSpan) Events() []trace.EventConfig {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]trace.EventConfig(nil), s.events...)
+}
+
+func newTestSpanContext() trace.SpanContext {
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+}
+
+func TestTraceCorrelation(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	ctx := trace.ContextWithSpanContext(context.Background(), newTestSpanContext())
+	multiLogger.LogContext(logger.INFO, ctx)
+	multiLogger.LogContext(logger.INFO, context.Background())
+	time.Sleep(50 * time.Millisecond)
+
+	messages := recorder.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+	if messages[0].TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || messages[0].SpanID != "00f067aa0ba902b7" || messages[0].TraceFlags != "01" {
+		t.Errorf("Unexpected trace correlation %q %q %q", messages[0].TraceID, messages[0].SpanID, messages[0].TraceFlags)
+	}
+	if messages[1].TraceID != "" || messages[1].SpanID != "" {
+		t.Error("Expected no trace fields without a span")
+	}
+}
+
+func TestSpanEvents(t *testing.T) {
+	span := &RecordingSpan{spanContext: newTestSpanContext()}
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	multiLogger := logger.NewLoggerWithOptions(100, []logger.ILogger{&RecordingLogger{}}, logger.WithSpanEvents())
+	defer multiLogger.Stop()
+
+	multiLogger.LogContext(logger.WARN, ctx)
+
+	events := span.Events()
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 span event, got %d", len(events))
+	}
+	attributes := events[0].Attributes()
+	if len(attributes) != 2 || attributes[0].Value.AsString() != "WARN" {
+		t.Errorf("Unexpected span event attributes %v", attributes)
+	}
+
+	withoutEvents := logger.NewLogger(100, &RecordingLogger{})
+	defer withoutEvents.Stop()
+	withoutEvents.LogContext(logger.WARN, ctx)
+	if len(span.Events()) != 1 {
+		t.Error("Expected span events to be opt-in")
+	}
+}
+
+func TestSpanEventsRedacted(t *testing.T) {
+	span := &RecordingSpan{spanContext: newTestSpanContext()}
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	multiLogger := logger.NewLoggerWithOptions(100, []logger.ILogger{&RecordingLogger{}},
+		logger.WithSpanEvents(), logger.WithRedactor(logger.NewRedactor()))
+	defer multiLogger.Stop()
+
+	multiLogger.LogContextf(logger.WARN, ctx, "signup from %s", "jane.doe@example.com")
+
+	events := span.Events()
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 span event, got %d", len(events))
+	}
+	for _, attribute := range events[0].Attributes() {
+		if attribute.Key == "log.message" && strings.Contains(attribute.Value.AsString(), "jane.doe@example.com") {
+			t.Errorf("Expected the span event message to be redacted, got %q", attribute.Value.AsString())
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CoreKitMDK/corekit-service-logger/v2/pkg/logger"
+)
+
+func TestWriter(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	writer := multiLogger.Writer(logger.WARN)
+	fmt.Fprint(writer, "first line\r\nsecond ")
+	fmt.Fprint(writer, "line\n\n")
+	fmt.Fprint(writer, "incomplete")
+	time.Sleep(50 * time.Millisecond)
+
+	messages := recorder.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 entries, got %+v", messages)
+	}
+	if messages[0].Level != "WARN" || !strings.HasSuffix(messages[0].Message, "first line") || !strings.HasSuffix(messages[1].Message, "second line") {
+		t.Errorf("Unexpected entries %+v", messages)
+	}
+
+	fmt.Fprint(writer, " line\n")
+	time.Sleep(50 * time.Millisecond)
+	if messages = recorder.Messages(); len(messages) != 3 || !strings.HasSuffix(messages[2].Message, "incomplete line") {
+		t.Errorf("Expected the completed line, got %+v", messages)
+	}
+}
+
+func TestWriterClose(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	// The last line of a command output often has no newline
+	writer := multiLogger.Writer(logger.INFO)
+	fmt.Fprint(writer, "line one\nno newline")
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	writer.Flush()
+	multiLogger.Flush(time.Second)
+
+	messages := recorder.Messages()
+	if len(messages) != 2 || !strings.HasSuffix(messages[0].Message, "line one") || !strings.HasSuffix(messages[1].Message, "no newline") {
+		t.Errorf("Expected the pending line to be logged once on Close, got %+v", messages)
+	}
+}
+
+func TestWriterConcurrent(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	stdLogger := logger.NewStdLogger(multiLogger, logger.INFO)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			stdLogger.Printf("worker %d done", i)
+		}(i)
+	}
+	wg.Wait()
+	time.Sleep(50 * time.Millisecond)
+
+	messages := recorder.Messages()
+	if len(messages) != 10 {
+		t.Fatalf("Expected 10 entries, got %d", len(messages))
+	}
+	for _, message := range messages {
+		if message.Level != "INFO" || !strings.Contains(message.Message, "] : worker ") {
+			t.Errorf("Unexpected entry %+v", message)
+		}
+	}
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	recorder := &RecordingLogger{}
+	multiLogger := logger.NewLogger(100, recorder)
+	defer multiLogger.Stop()
+
+	output, flags := log.Writer(), log.Flags()
+	defer func() {
+		log.SetOutput(output)
+		log.SetFlags(flags)
+	}()
+
+	var previous bytes.Buffer
+	log.SetOutput(&previous)
+	log.SetFlags(log.Lshortfile)
+
+	restore := multiLogger.RedirectStdLog(logger.ERROR)
+	log.Print("from the standard library")
+	restore()
+	log.Print("after restore")
+	time.Sleep(50 * time.Millisecond)
+
+	messages := recorder.Messages()
+	if len(messages) != 1 || messages[0].Level != "ERROR" || !strings.HasSuffix(messages[0].Message, "] : from the standard library") {
+		t.Fatalf("Expected the redirected entry without the log prefix, got %+v", messages)
+	}
+	if !strings.Contains(previous.String(), "after restore") || log.Flags() != log.Lshortfile {
+		t.Errorf("Expected the previous output and flags to be restored, got %q", previous.String())
+	}
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package tests
+
+// raceEnabled is set when the race detector is on, it makes sync.Pool drop items at random
+const raceEnabled = true